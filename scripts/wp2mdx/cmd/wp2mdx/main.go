@@ -3,22 +3,40 @@ package main
 import (
 	"fmt"
 	"os"
+	"sort"
+	"strings"
 	"sync"
 	"time"
+	_ "time/tzdata"
 
+	"github.com/aequinox/gesundes-leben/wp2mdx/pkg/authors"
+	"github.com/aequinox/gesundes-leben/wp2mdx/pkg/classify"
 	"github.com/aequinox/gesundes-leben/wp2mdx/pkg/config"
 	"github.com/aequinox/gesundes-leben/wp2mdx/pkg/frontmatter"
+	"github.com/aequinox/gesundes-leben/wp2mdx/pkg/glossary"
 	"github.com/aequinox/gesundes-leben/wp2mdx/pkg/images"
+	"github.com/aequinox/gesundes-leben/wp2mdx/pkg/keywords"
+	"github.com/aequinox/gesundes-leben/wp2mdx/pkg/links"
 	"github.com/aequinox/gesundes-leben/wp2mdx/pkg/models"
 	"github.com/aequinox/gesundes-leben/wp2mdx/pkg/parser"
+	"github.com/aequinox/gesundes-leben/wp2mdx/pkg/redirects"
+	"github.com/aequinox/gesundes-leben/wp2mdx/pkg/references"
+	"github.com/aequinox/gesundes-leben/wp2mdx/pkg/schema"
 	"github.com/aequinox/gesundes-leben/wp2mdx/pkg/writer"
 	"github.com/schollz/progressbar/v3"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 var (
-	cfg     *config.Config
-	version = "1.0.0"
+	cfg             *config.Config
+	version         = "1.0.0"
+	configFile      string
+	configInitForce bool
+	timeoutSecs     int
+	redirectsDir    string
+	redirectFormats []string
+	referenceReport string
 )
 
 func main() {
@@ -56,70 +74,224 @@ var listCmd = &cobra.Command{
 	RunE:  runList,
 }
 
+var classifyCmd = &cobra.Command{
+	Use:   "classify",
+	Short: "Preview group classification",
+	Long:  "Prints the group, confidence and deciding rule for every post, for review before conversion.",
+	RunE:  runClassify,
+}
+
+var redirectsCmd = &cobra.Command{
+	Use:   "redirects",
+	Short: "Generate redirects from WordPress URLs",
+	Long:  "Writes 301 redirects from the WordPress URLs of posts, attachment pages and category and tag archives to the new routes, for Netlify, Vercel, nginx, Apache and Astro.",
+	RunE:  runRedirects,
+}
+
+var referencesCmd = &cobra.Command{
+	Use:   "references",
+	Short: "Preview reference matching",
+	Long:  "Extracts the citations of every post and prints a merge report of which link to existing entries of the references collection and which would be created. No entries are written.",
+	RunE:  runReferences,
+}
+
 var categoriesCmd = &cobra.Command{
 	Use:   "categories",
 	Short: "Show category mapping",
 	Long:  "Displays the WordPress to German category mapping.",
-	Run:   runCategories,
+	RunE:  runCategories,
+}
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Manage project config files",
+	Long:  "Creates and inspects YAML or TOML project config files for wp2mdx.",
+}
+
+var configInitCmd = &cobra.Command{
+	Use:   "init [file]",
+	Short: "Write a commented default config file",
+	Long:  "Writes a config file with all options at their default values (default: wp2mdx.yaml).",
+	Args:  cobra.MaximumNArgs(1),
+	RunE:  runConfigInit,
+}
+
+var configShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Show the effective configuration",
+	Long:  "Prints the configuration after merging defaults, the config file and flags.",
+	RunE:  runConfigShow,
 }
 
 func init() {
 	cfg = config.DefaultConfig()
 
 	// Root command flags
+	rootCmd.PersistentFlags().StringVarP(&configFile, "config", "c", "", "project config file (YAML or TOML)")
 	rootCmd.PersistentFlags().BoolVarP(&cfg.Verbose, "verbose", "v", cfg.Verbose, "verbose output")
 	rootCmd.PersistentFlags().BoolVarP(&cfg.Quiet, "quiet", "q", cfg.Quiet, "quiet mode (errors only)")
+	rootCmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		if err := resolveConfig(cmd); err != nil {
+			return fmt.Errorf("invalid configuration: %w", err)
+		}
+		return nil
+	}
 
-	// Convert command flags
-	convertCmd.Flags().StringVarP(&cfg.InputFile, "input", "i", "", "input WordPress XML file (required)")
-	convertCmd.Flags().StringVarP(&cfg.OutputDir, "output", "o", cfg.OutputDir, "output directory")
+	// Convert command flags; config show accepts them too so it can print
+	// the effective result of a convert invocation
+	addConvertFlags(convertCmd.Flags())
+	addConvertFlags(configShowCmd.Flags())
+
+	configInitCmd.Flags().BoolVar(&configInitForce, "force", false, "overwrite an existing config file")
+
+	// Add commands
+	rootCmd.AddCommand(convertCmd)
+	rootCmd.AddCommand(validateCmd)
+	rootCmd.AddCommand(listCmd)
+	rootCmd.AddCommand(classifyCmd)
+	rootCmd.AddCommand(redirectsCmd)
+	rootCmd.AddCommand(referencesCmd)
+	rootCmd.AddCommand(categoriesCmd)
+	rootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configInitCmd)
+	configCmd.AddCommand(configShowCmd)
+
+	// Validate and list use the same input flag
+	validateCmd.Flags().StringVarP(&cfg.InputFile, "input", "i", "", "input WordPress XML file (required)")
+	listCmd.Flags().StringVarP(&cfg.InputFile, "input", "i", "", "input WordPress XML file (required)")
+
+	classifyCmd.Flags().StringVarP(&cfg.InputFile, "input", "i", "", "input WordPress XML file (required)")
+	classifyCmd.Flags().StringVar(&cfg.GroupRules, "group-rules", cfg.GroupRules, "JSON file with group classification rules")
+	classifyCmd.Flags().StringVar(&cfg.SchemaFile, "schema", cfg.SchemaFile, "JSON file overriding the blog frontmatter schema")
+	classifyCmd.Flags().BoolVar(&cfg.IncludeDrafts, "include-drafts", cfg.IncludeDrafts, "include draft posts")
+	classifyCmd.Flags().BoolVar(&cfg.IncludePages, "include-pages", cfg.IncludePages, "include pages")
+	classifyCmd.Flags().BoolVar(&cfg.IncludeTypes, "include-types", cfg.IncludeTypes, "include custom post types")
+
+	redirectsCmd.Flags().StringVarP(&cfg.InputFile, "input", "i", "", "input WordPress XML file (required)")
+	redirectsCmd.Flags().StringVarP(&redirectsDir, "output", "o", "redirects", "directory to write the redirect files to")
+	redirectsCmd.Flags().StringSliceVar(&redirectFormats, "format", redirects.Formats, "redirect formats to write ("+strings.Join(redirects.Formats, ", ")+")")
+	redirectsCmd.Flags().BoolVar(&cfg.IncludeDrafts, "include-drafts", cfg.IncludeDrafts, "include draft posts")
+	redirectsCmd.Flags().BoolVar(&cfg.IncludePages, "include-pages", cfg.IncludePages, "include pages")
+	redirectsCmd.Flags().BoolVar(&cfg.IncludeTypes, "include-types", cfg.IncludeTypes, "include custom post types")
+	redirectsCmd.Flags().StringVar(&cfg.CategoryMappingFile, "category-mapping", "", "JSON file for category mapping")
+	redirectsCmd.Flags().BoolVar(&cfg.DryRun, "dry-run", cfg.DryRun, "print the redirects without writing files")
+
+	referencesCmd.Flags().StringVarP(&cfg.InputFile, "input", "i", "", "input WordPress XML file (required)")
+	referencesCmd.Flags().StringVarP(&referenceReport, "output", "o", "", "file to write the report to (default: stdout)")
+	referencesCmd.Flags().StringVar(&cfg.ReferencesDir, "references-dir", cfg.ReferencesDir, "references collection directory to match against")
+	referencesCmd.Flags().Float64Var(&cfg.ReferenceThreshold, "reference-threshold", cfg.ReferenceThreshold, "minimum score (0-1) for linking a citation to an entry by title, author and year")
+	referencesCmd.Flags().BoolVar(&cfg.IncludeDrafts, "include-drafts", cfg.IncludeDrafts, "include draft posts")
+	referencesCmd.Flags().BoolVar(&cfg.IncludePages, "include-pages", cfg.IncludePages, "include pages")
+	referencesCmd.Flags().BoolVar(&cfg.IncludeTypes, "include-types", cfg.IncludeTypes, "include custom post types")
+
+	categoriesCmd.Flags().StringVar(&cfg.CategoryMappingFile, "category-mapping", "", "JSON file for category mapping")
+}
+
+// addConvertFlags registers the conversion options on a flag set
+func addConvertFlags(flags *pflag.FlagSet) {
+	flags.StringVarP(&cfg.InputFile, "input", "i", "", "input WordPress XML file (required, or set in config file)")
+	flags.StringVarP(&cfg.OutputDir, "output", "o", cfg.OutputDir, "output directory")
 
 	// Organization flags
-	convertCmd.Flags().BoolVar(&cfg.YearFolders, "year-folders", cfg.YearFolders, "organize posts into year folders")
-	convertCmd.Flags().BoolVar(&cfg.MonthFolders, "month-folders", cfg.MonthFolders, "organize into month folders (requires --year-folders)")
-	convertCmd.Flags().BoolVar(&cfg.PostFolders, "post-folders", cfg.PostFolders, "create individual folder per post")
-	convertCmd.Flags().BoolVar(&cfg.PrefixDate, "prefix-date", cfg.PrefixDate, "prefix filenames with date")
+	flags.BoolVar(&cfg.YearFolders, "year-folders", cfg.YearFolders, "organize posts into year folders")
+	flags.BoolVar(&cfg.MonthFolders, "month-folders", cfg.MonthFolders, "organize into month folders (requires --year-folders)")
+	flags.BoolVar(&cfg.PostFolders, "post-folders", cfg.PostFolders, "create individual folder per post")
+	flags.BoolVar(&cfg.PrefixDate, "prefix-date", cfg.PrefixDate, "prefix filenames with date")
 
 	// Image processing flags
-	convertCmd.Flags().BoolVar(&cfg.DownloadImages, "download-images", cfg.DownloadImages, "download images")
-	convertCmd.Flags().BoolVar(&cfg.DownloadAttached, "download-attached", cfg.DownloadAttached, "download attached images")
-	convertCmd.Flags().BoolVar(&cfg.DownloadScraped, "download-scraped", cfg.DownloadScraped, "download content images")
-	convertCmd.Flags().IntVar(&cfg.ImageQuality, "image-quality", cfg.ImageQuality, "image quality (1-100)")
-	convertCmd.Flags().IntVar(&cfg.MaxImageWidth, "max-image-width", cfg.MaxImageWidth, "maximum image width")
-	convertCmd.Flags().StringVar(&cfg.ImageBaseURL, "image-base-url", cfg.ImageBaseURL, "base URL for relative image paths")
+	flags.BoolVar(&cfg.DownloadImages, "download-images", cfg.DownloadImages, "download images")
+	flags.BoolVar(&cfg.DownloadAttached, "download-attached", cfg.DownloadAttached, "download attached images")
+	flags.BoolVar(&cfg.DownloadScraped, "download-scraped", cfg.DownloadScraped, "download content images")
+	flags.IntVar(&cfg.ImageQuality, "image-quality", cfg.ImageQuality, "image quality (1-100)")
+	flags.IntVar(&cfg.MaxImageWidth, "max-image-width", cfg.MaxImageWidth, "maximum image width")
+	flags.StringVar(&cfg.ImageBaseURL, "image-base-url", cfg.ImageBaseURL, "base URL for relative image paths")
+	flags.StringVar(&cfg.ImageCacheDir, "image-cache", cfg.ImageCacheDir, "directory of the shared image download cache (default: user cache dir)")
 
 	// Processing flags
-	convertCmd.Flags().IntVar(&cfg.Concurrency, "concurrency", cfg.Concurrency, "number of concurrent workers")
-	convertCmd.Flags().BoolVar(&cfg.IncludeDrafts, "include-drafts", cfg.IncludeDrafts, "include draft posts")
-	convertCmd.Flags().BoolVar(&cfg.IncludePages, "include-pages", cfg.IncludePages, "include pages")
-	convertCmd.Flags().BoolVar(&cfg.IncludeTypes, "include-types", cfg.IncludeTypes, "include custom post types")
+	flags.IntVar(&cfg.Concurrency, "concurrency", cfg.Concurrency, "number of concurrent workers")
+	flags.BoolVar(&cfg.IncludeDrafts, "include-drafts", cfg.IncludeDrafts, "include draft posts")
+	flags.BoolVar(&cfg.IncludePages, "include-pages", cfg.IncludePages, "include pages")
+	flags.BoolVar(&cfg.IncludeTypes, "include-types", cfg.IncludeTypes, "include custom post types")
+	flags.StringVar(&cfg.Timezone, "timezone", cfg.Timezone, "site timezone for WordPress local dates (IANA name)")
+	flags.IntVar(&cfg.ReadingWPM, "reading-wpm", cfg.ReadingWPM, "reading speed in words per minute for the reading time")
+	flags.IntVar(&cfg.ReadingImageSeconds, "reading-image-seconds", cfg.ReadingImageSeconds, "seconds added to the reading time per image")
+	flags.BoolVar(&cfg.RewriteLinks, "rewrite-links", cfg.RewriteLinks, "rewrite links between posts to their new routes")
+	flags.BoolVar(&cfg.LinkComponent, "link-component", cfg.LinkComponent, "rewrite internal links as InternalLink components")
+	flags.BoolVar(&cfg.ExtractReferences, "extract-references", cfg.ExtractReferences, "link citations to the references collection")
+	flags.StringVar(&cfg.ReferencesDir, "references-dir", cfg.ReferencesDir, "references collection directory for matching and new entries")
+	flags.Float64Var(&cfg.ReferenceThreshold, "reference-threshold", cfg.ReferenceThreshold, "minimum score (0-1) for linking a citation to an entry by title, author and year")
+	flags.StringVar(&cfg.ReferenceReport, "reference-report", cfg.ReferenceReport, "Markdown file to write the reference merge report to")
+	flags.BoolVar(&cfg.LinkGlossary, "link-glossary", cfg.LinkGlossary, "link the first mention of each glossary term")
+	flags.StringVar(&cfg.GlossaryDir, "glossary-dir", cfg.GlossaryDir, "glossary collection directory")
 
 	// Output control flags
-	convertCmd.Flags().BoolVar(&cfg.DryRun, "dry-run", cfg.DryRun, "preview without writing files")
-	convertCmd.Flags().BoolVar(&cfg.Force, "force", cfg.Force, "overwrite existing files")
+	flags.BoolVar(&cfg.DryRun, "dry-run", cfg.DryRun, "preview without writing files")
+	flags.BoolVar(&cfg.Force, "force", cfg.Force, "overwrite existing files")
+
+	// Validation flags
+	flags.StringVar(&cfg.SchemaFile, "schema", cfg.SchemaFile, "JSON file overriding the blog frontmatter schema")
+	flags.BoolVar(&cfg.StrictSchema, "strict-schema", cfg.StrictSchema, "skip posts whose frontmatter fails schema validation")
+	flags.StringVar(&cfg.GroupRules, "group-rules", cfg.GroupRules, "JSON file with group classification rules")
 
 	// Advanced flags
-	var authorMappingFile, categoryMappingFile string
-	var timeoutSecs int
-	convertCmd.Flags().StringVar(&authorMappingFile, "author-mapping", "", "JSON file for author mapping")
-	convertCmd.Flags().StringVar(&categoryMappingFile, "category-mapping", "", "JSON file for category mapping")
-	convertCmd.Flags().IntVar(&timeoutSecs, "timeout", 30, "HTTP timeout in seconds")
+	flags.StringVar(&cfg.AuthorMappingFile, "author-mapping", "", "JSON file for author mapping")
+	flags.StringVar(&cfg.AuthorsDir, "authors-dir", cfg.AuthorsDir, "authors collection directory for matching and author stubs")
+	flags.StringVar(&cfg.CategoryMappingFile, "category-mapping", "", "JSON file for category mapping")
+	flags.IntVar(&timeoutSecs, "timeout", int(cfg.Timeout.Seconds()), "HTTP timeout in seconds")
+}
 
-	// Mark required flags
-	convertCmd.MarkFlagRequired("input")
+// resolveConfig merges the configuration in order of precedence (defaults,
+// config file, flags), applies flag values that need conversion and loads
+// the mapping files referenced by the configuration
+func resolveConfig(cmd *cobra.Command) error {
+	if configFile != "" {
+		// Loading the file overwrites flag-bound fields, so remember the
+		// flags given on the command line and re-apply them afterwards.
+		// Setting a slice flag appends, so slices are replaced instead.
+		changed := make(map[string]string)
+		changedSlices := make(map[string][]string)
+		cmd.Flags().Visit(func(f *pflag.Flag) {
+			if v, ok := f.Value.(pflag.SliceValue); ok {
+				changedSlices[f.Name] = v.GetSlice()
+				return
+			}
+			changed[f.Name] = f.Value.String()
+		})
 
-	// Add commands
-	rootCmd.AddCommand(convertCmd)
-	rootCmd.AddCommand(validateCmd)
-	rootCmd.AddCommand(listCmd)
-	rootCmd.AddCommand(categoriesCmd)
+		if err := cfg.LoadFile(configFile); err != nil {
+			return err
+		}
 
-	// Validate and list use the same input flag
-	validateCmd.Flags().StringVarP(&cfg.InputFile, "input", "i", "", "input WordPress XML file (required)")
-	validateCmd.MarkFlagRequired("input")
+		for name, value := range changed {
+			if err := cmd.Flags().Set(name, value); err != nil {
+				return fmt.Errorf("failed to apply --%s: %w", name, err)
+			}
+		}
+		for name, values := range changedSlices {
+			if err := cmd.Flags().Lookup(name).Value.(pflag.SliceValue).Replace(values); err != nil {
+				return fmt.Errorf("failed to apply --%s: %w", name, err)
+			}
+		}
 
-	listCmd.Flags().StringVarP(&cfg.InputFile, "input", "i", "", "input WordPress XML file (required)")
-	listCmd.MarkFlagRequired("input")
+		logVerbose("⚙️  Loaded config file %s", configFile)
+	}
+
+	if cmd.Flags().Changed("timeout") {
+		cfg.Timeout = time.Duration(timeoutSecs) * time.Second
+	}
+
+	if err := cfg.Resolve(); err != nil {
+		return err
+	}
+
+	if cfg.AuthorMappingFile != "" {
+		logVerbose("👤 Loaded %d author mappings from %s", len(cfg.AuthorMapping), cfg.AuthorMappingFile)
+	}
+	if cfg.CategoryMappingFile != "" {
+		logVerbose("🏷️  Loaded category mapping from %s", cfg.CategoryMappingFile)
+	}
+
+	return nil
 }
 
 func runConvert(cmd *cobra.Command, args []string) error {
@@ -130,21 +302,79 @@ func runConvert(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("invalid configuration: %w", err)
 	}
 
+	frontmatterSchema, err := schema.Load(cfg.SchemaFile)
+	if err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	classifier, err := loadClassifier(frontmatterSchema)
+	if err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+
 	logInfo("🚀 WordPress XML to MDX Converter v%s", version)
 	logInfo("📁 Input: %s", cfg.InputFile)
 	logInfo("📁 Output: %s", cfg.OutputDir)
 
-	// Parse XML
+	// Stream XML, indexing attachments and keeping only the posts we convert
 	logInfo("📖 Parsing WordPress XML...")
 	p := parser.New(cfg.InputFile)
-	export, err := p.Parse()
+	attachments := parser.NewAttachmentIndex()
+	linkIndex := links.NewIndex()
+	var items []models.Item
+
+	channel, err := p.Stream(func(item *models.Item) error {
+		linkIndex.Add(item)
+		if item.PostType == "attachment" {
+			attachments.Add(item)
+			return nil
+		}
+		if parser.IncludePost(item, cfg.IncludeDrafts, cfg.IncludePages, cfg.IncludeTypes) {
+			items = append(items, *item)
+		}
+		return nil
+	})
 	if err != nil {
 		return fmt.Errorf("failed to parse XML: %w", err)
 	}
+	linkIndex.SetSiteURL(channel.Link)
+
+	logInfo("🔍 Indexed %d attachments", attachments.Len())
 
-	// Filter posts
-	logInfo("🔍 Filtering posts...")
-	items := parser.FilterPosts(export.Channel.Items, cfg.IncludeDrafts, cfg.IncludePages, cfg.IncludeTypes)
+	authorResolver, err := authors.Load(cfg.AuthorsDir, cfg.AuthorMapping, channel.Authors)
+	if err != nil {
+		return fmt.Errorf("failed to load authors: %w", err)
+	}
+	if authorResolver.Dir() == "" {
+		logWarn("Authors directory %s not found, author stubs will not be created", cfg.AuthorsDir)
+	}
+	logAuthorMapping(authorResolver, channel.Authors)
+
+	var referenceLibrary *references.Library
+	if cfg.ExtractReferences {
+		referenceLibrary, err = references.LoadLibrary(cfg.ReferencesDir)
+		if err != nil {
+			return fmt.Errorf("failed to load references: %w", err)
+		}
+		referenceLibrary.SetThreshold(cfg.ReferenceThreshold)
+		if referenceLibrary.Dir() == "" {
+			logWarn("References directory %s not found, new references will not be written", cfg.ReferencesDir)
+		} else {
+			logVerbose("📚 Loaded %d references from %s", referenceLibrary.Len(), cfg.ReferencesDir)
+		}
+	}
+	var glossaryTerms *glossary.Glossary
+	if cfg.LinkGlossary {
+		glossaryTerms, err = glossary.Load(cfg.GlossaryDir)
+		if err != nil {
+			return fmt.Errorf("failed to load glossary: %w", err)
+		}
+		if glossaryTerms.Len() == 0 {
+			logWarn("No glossary terms found in %s, terms will not be linked", cfg.GlossaryDir)
+		} else {
+			logVerbose("📖 Loaded %d glossary terms from %s", glossaryTerms.Len(), cfg.GlossaryDir)
+		}
+	}
 	logInfo("📝 Found %d posts to process", len(items))
 
 	if len(items) == 0 {
@@ -155,22 +385,31 @@ func runConvert(cmd *cobra.Command, args []string) error {
 	// Build posts
 	logInfo("🏗️  Building post models...")
 	gen := frontmatter.New(cfg)
+	gen.SetKeywordCorpus(buildKeywordCorpus(items))
+	gen.SetClassifier(classifier)
+	gen.SetAuthorResolver(authorResolver)
+	if referenceLibrary != nil {
+		gen.SetReferenceLibrary(referenceLibrary)
+	}
 	posts := make([]*models.Post, 0, len(items))
 
 	for i := range items {
-		post, err := gen.BuildPost(&items[i], export.Channel.Items)
+		post, err := gen.BuildPost(&items[i], attachments)
 		if err != nil {
 			logWarn("Failed to build post %d: %v", items[i].PostID, err)
 			continue
 		}
 		posts = append(posts, post)
+		if target := linkIndex.ByID(items[i].PostID); target != nil {
+			target.Converted = true
+		}
 	}
 
 	logInfo("✅ Built %d post models", len(posts))
 
 	// Process posts concurrently
 	logInfo("⚙️  Processing posts...")
-	stats, err := processPosts(posts)
+	stats, err := processPosts(posts, attachments, linkIndex, glossaryTerms, frontmatterSchema)
 	if err != nil {
 		return fmt.Errorf("failed to process posts: %w", err)
 	}
@@ -182,10 +421,30 @@ func runConvert(cmd *cobra.Command, args []string) error {
 	logInfo("   Posts processed: %d", stats.PostsProcessed)
 	logInfo("   Posts skipped: %d", stats.PostsSkipped)
 	logInfo("   Images downloaded: %d", stats.ImagesDownloaded)
+	logInfo("   Images reused from cache: %d", stats.ImagesReused)
 	logInfo("   Images failed: %d", stats.ImagesFailed)
+	if stats.ImageBytes > 0 {
+		logInfo("   Image size: %s downloaded, %s saved by processing",
+			formatBytes(stats.ImageBytes), formatBytes(stats.ImageBytesSaved))
+	}
 	logInfo("   Duration: %v", duration.Round(time.Millisecond))
 	logInfo("   Rate: %.1f posts/sec", float64(stats.PostsProcessed)/duration.Seconds())
 
+	if err := reportAuthors(authorResolver); err != nil {
+		stats.Errors = append(stats.Errors, err)
+	}
+	if referenceLibrary != nil {
+		if err := reportReferences(referenceLibrary); err != nil {
+			stats.Errors = append(stats.Errors, err)
+		}
+	}
+
+	if glossaryTerms != nil {
+		reportGlossary(posts)
+	}
+
+	reportWarnings(posts)
+
 	if len(stats.Errors) > 0 {
 		logWarn("⚠️  %d errors occurred during conversion", len(stats.Errors))
 		for _, err := range stats.Errors {
@@ -196,14 +455,31 @@ func runConvert(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
-func processPosts(posts []*models.Post) (*models.ConversionStats, error) {
+// buildKeywordCorpus collects term frequencies across all posts, so that
+// keywords are chosen by what sets a post apart from the others
+func buildKeywordCorpus(items []models.Item) *keywords.Corpus {
+	corpus := keywords.NewCorpus()
+	for i := range items {
+		corpus.Add(keywords.NewDocument(items[i].Title, items[i].Content, parser.GetTags(&items[i])))
+	}
+	return corpus
+}
+
+func processPosts(posts []*models.Post, attachments *parser.AttachmentIndex, linkIndex *links.Index, g *glossary.Glossary, s *schema.Schema) (*models.ConversionStats, error) {
 	stats := &models.ConversionStats{
 		StartTime: time.Now(),
 	}
 
 	// Create workers
-	w := writer.New(cfg)
-	imgDownloader := images.New(cfg)
+	w := writer.New(cfg, attachments, s)
+	w.SetLinkIndex(linkIndex)
+	if g != nil && g.Len() > 0 {
+		w.SetGlossary(g)
+	}
+	imgDownloader, err := images.New(cfg, attachments)
+	if err != nil {
+		return nil, err
+	}
 
 	// Progress bar
 	var bar *progressbar.ProgressBar
@@ -223,6 +499,18 @@ func processPosts(posts []*models.Post) (*models.ConversionStats, error) {
 			semaphore <- struct{}{}        // Acquire
 			defer func() { <-semaphore }() // Release
 
+			// Validate before downloading, so failing posts leave no files
+			if err := w.Validate(p); err != nil {
+				mu.Lock()
+				stats.Errors = append(stats.Errors, fmt.Errorf("write failed for %s: %w", p.Title, err))
+				stats.PostsSkipped++
+				mu.Unlock()
+				if bar != nil {
+					bar.Add(1)
+				}
+				return
+			}
+
 			// Determine output directory for images
 			outputDir, _ := w.GetOutputDirectory(p)
 
@@ -254,35 +542,48 @@ func processPosts(posts []*models.Post) (*models.ConversionStats, error) {
 	wg.Wait()
 	stats.EndTime = time.Now()
 
+	if err := imgDownloader.Close(); err != nil {
+		stats.Errors = append(stats.Errors, err)
+	}
+
 	// Get image stats
 	imgStats := imgDownloader.GetStats()
 	stats.ImagesDownloaded = imgStats.Downloaded
 	stats.ImagesFailed = imgStats.Failed
+	stats.ImagesReused = imgStats.Reused
+	stats.ImageBytes = imgStats.TotalBytes
+	stats.ImageBytesSaved = imgStats.BytesSaved
 
 	return stats, nil
 }
 
 func runValidate(cmd *cobra.Command, args []string) error {
+	if err := requireInput(); err != nil {
+		return err
+	}
+
 	logInfo("🔍 Validating WordPress XML file...")
 
+	// Count by type while streaming
+	typeCounts := make(map[string]int)
+	total := 0
+
 	p := parser.New(cfg.InputFile)
-	export, err := p.Parse()
+	channel, err := p.Stream(func(item *models.Item) error {
+		typeCounts[item.PostType]++
+		total++
+		return nil
+	})
 	if err != nil {
 		return fmt.Errorf("validation failed: %w", err)
 	}
 
 	logInfo("✅ XML file is valid")
 	logInfo("📊 Summary:")
-	logInfo("   Site: %s", export.Channel.Title)
-	logInfo("   Language: %s", export.Channel.Language)
-	logInfo("   Total items: %d", len(export.Channel.Items))
-	logInfo("   Authors: %d", len(export.Channel.Authors))
-
-	// Count by type
-	typeCounts := make(map[string]int)
-	for _, item := range export.Channel.Items {
-		typeCounts[item.PostType]++
-	}
+	logInfo("   Site: %s", channel.Title)
+	logInfo("   Language: %s", channel.Language)
+	logInfo("   Total items: %d", total)
+	logInfo("   Authors: %d", len(channel.Authors))
 
 	logInfo("   By type:")
 	for postType, count := range typeCounts {
@@ -293,14 +594,22 @@ func runValidate(cmd *cobra.Command, args []string) error {
 }
 
 func runList(cmd *cobra.Command, args []string) error {
+	if err := requireInput(); err != nil {
+		return err
+	}
+
 	p := parser.New(cfg.InputFile)
-	export, err := p.Parse()
+	var items []models.Item
+	_, err := p.Stream(func(item *models.Item) error {
+		if parser.IncludePost(item, true, true, true) {
+			items = append(items, *item)
+		}
+		return nil
+	})
 	if err != nil {
 		return fmt.Errorf("failed to parse XML: %w", err)
 	}
 
-	items := parser.FilterPosts(export.Channel.Items, true, true, true)
-
 	fmt.Printf("Found %d posts:\n\n", len(items))
 	for i, item := range items {
 		fmt.Printf("%4d. [%s] %s\n", i+1, item.PostType, item.Title)
@@ -310,13 +619,376 @@ func runList(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
-func runCategories(cmd *cobra.Command, args []string) {
+func runClassify(cmd *cobra.Command, args []string) error {
+	if err := requireInput(); err != nil {
+		return err
+	}
+
+	frontmatterSchema, err := schema.Load(cfg.SchemaFile)
+	if err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+	classifier, err := loadClassifier(frontmatterSchema)
+	if err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	gen := frontmatter.New(cfg)
+	gen.SetClassifier(classifier)
+
+	counts := make(map[string]int)
+	total := 0
+
+	p := parser.New(cfg.InputFile)
+	_, err = p.Stream(func(item *models.Item) error {
+		if !parser.IncludePost(item, cfg.IncludeDrafts, cfg.IncludePages, cfg.IncludeTypes) {
+			return nil
+		}
+
+		result := gen.Classify(item)
+		counts[result.Group]++
+		total++
+
+		fmt.Printf("%6d  %-11s %4.0f%%  %s\n", item.PostID, result.Group, result.Confidence*100, item.Title)
+		fmt.Printf("        rule: %s", result.Rule)
+		if groups := result.SortedGroups(); len(groups) > 0 {
+			scores := make([]string, len(groups))
+			for i, group := range groups {
+				scores[i] = fmt.Sprintf("%s %.1f", group, result.Scores[group])
+			}
+			fmt.Printf(" (%s)", strings.Join(scores, ", "))
+		}
+		fmt.Println()
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to parse XML: %w", err)
+	}
+
+	fmt.Printf("\nClassified %d posts:", total)
+	for _, group := range frontmatterSchema.Groups {
+		fmt.Printf(" %s %d", group, counts[group])
+	}
+	fmt.Println()
+
+	return nil
+}
+
+func runRedirects(cmd *cobra.Command, args []string) error {
+	if err := requireInput(); err != nil {
+		return err
+	}
+	for _, name := range redirectFormats {
+		if _, err := redirects.Filename(name); err != nil {
+			return err
+		}
+	}
+
+	// Items are converted if they pass the same filters as in convert
+	index := links.NewIndex()
+	builder := redirects.New(index, cfg.GetCategory)
+
+	p := parser.New(cfg.InputFile)
+	_, err := p.Stream(func(item *models.Item) error {
+		target := index.Add(item)
+		target.Converted = parser.IncludePost(item, cfg.IncludeDrafts, cfg.IncludePages, cfg.IncludeTypes)
+		if target.Converted {
+			builder.AddItem(item)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to parse XML: %w", err)
+	}
+
+	list, notes := builder.Build()
+
+	counts := make(map[string]int)
+	for _, r := range list {
+		counts[r.Source]++
+		logVerbose("   %s → %s", r.From, r.To)
+	}
+	logInfo("↪️  %d redirects (posts %d, pages %d, attachments %d, categories %d, tags %d)",
+		len(list), counts["post"], counts["page"], counts["attachment"], counts["category"], counts["tag"])
+	for _, note := range notes {
+		logWarn("%s", note)
+	}
+
+	if cfg.DryRun {
+		for _, r := range list {
+			fmt.Printf("%s %s\n", r.From, r.To)
+		}
+		return nil
+	}
+
+	written, err := redirects.Write(redirectsDir, redirectFormats, list)
+	for _, filename := range written {
+		logInfo("📝 Wrote %s", filename)
+	}
+	return err
+}
+
+func runReferences(cmd *cobra.Command, args []string) error {
+	if err := requireInput(); err != nil {
+		return err
+	}
+	if cfg.ReferenceThreshold <= 0 || cfg.ReferenceThreshold > 1 {
+		return fmt.Errorf("reference threshold must be greater than 0 and at most 1")
+	}
+
+	library, err := references.LoadLibrary(cfg.ReferencesDir)
+	if err != nil {
+		return fmt.Errorf("failed to load references: %w", err)
+	}
+	if library.Dir() == "" {
+		logWarn("References directory %s not found, all citations count as new", cfg.ReferencesDir)
+	}
+	library.SetThreshold(cfg.ReferenceThreshold)
+
+	p := parser.New(cfg.InputFile)
+	_, err = p.Stream(func(item *models.Item) error {
+		if !parser.IncludePost(item, cfg.IncludeDrafts, cfg.IncludePages, cfg.IncludeTypes) {
+			return nil
+		}
+
+		refs, warnings, err := references.Extract(item.Content)
+		if err != nil {
+			logWarn("Failed to extract references of post %d: %v", item.PostID, err)
+			return nil
+		}
+		for _, warning := range warnings {
+			logWarn("[%d] %s", item.PostID, warning)
+		}
+		source := fmt.Sprintf("[%d] %s", item.PostID, item.Title)
+		for _, ref := range refs {
+			library.Resolve(ref, source)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to parse XML: %w", err)
+	}
+
+	if referenceReport == "" {
+		return library.WriteReport(os.Stdout)
+	}
+	if err := writeReferenceReport(library, referenceReport); err != nil {
+		return err
+	}
+	logInfo("📚 Wrote reference report %s (%d linked, %d new)", referenceReport, len(library.Linked()), len(library.Created()))
+	return nil
+}
+
+// writeReferenceReport writes the merge report of a library to a file
+func writeReferenceReport(library *references.Library, filename string) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return fmt.Errorf("failed to create reference report: %w", err)
+	}
+	if err := library.WriteReport(f); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write reference report: %w", err)
+	}
+	return nil
+}
+
+// loadClassifier reads the group rules from the configuration and checks
+// them against the schema's groups
+func loadClassifier(s *schema.Schema) (*classify.Classifier, error) {
+	rules, err := classify.Load(cfg.GroupRules)
+	if err != nil {
+		return nil, err
+	}
+	if err := rules.Validate(s.Groups); err != nil {
+		return nil, err
+	}
+	return classify.New(rules), nil
+}
+
+func runCategories(cmd *cobra.Command, args []string) error {
 	fmt.Println("Category Mapping (WordPress → German):")
 	fmt.Println()
 
-	for wp, de := range cfg.CategoryMapping {
-		fmt.Printf("  %-20s → %s\n", wp, de)
+	keys := make([]string, 0, len(cfg.CategoryMapping))
+	for wp := range cfg.CategoryMapping {
+		keys = append(keys, wp)
+	}
+	sort.Strings(keys)
+
+	for _, wp := range keys {
+		fmt.Printf("  %-20s → %s\n", wp, cfg.CategoryMapping[wp])
+	}
+
+	return nil
+}
+
+func runConfigInit(cmd *cobra.Command, args []string) error {
+	filename := "wp2mdx.yaml"
+	if len(args) > 0 {
+		filename = args[0]
+	}
+
+	if err := config.WriteDefaultFile(filename, configInitForce); err != nil {
+		return err
+	}
+
+	logInfo("✅ Wrote default config to %s", filename)
+	return nil
+}
+
+func runConfigShow(cmd *cobra.Command, args []string) error {
+	out, err := cfg.ToYAML()
+	if err != nil {
+		return err
+	}
+
+	fmt.Print(out)
+	return nil
+}
+
+// requireInput ensures an input file was given by flag or config file
+func requireInput() error {
+	if cfg.InputFile == "" {
+		return fmt.Errorf("input file is required (--input or config file)")
+	}
+	return nil
+}
+
+// reportWarnings prints the warnings collected for each post
+func reportWarnings(posts []*models.Post) {
+	count := 0
+	for _, post := range posts {
+		if len(post.Warnings) > 0 {
+			count++
+		}
+	}
+	if count == 0 {
+		return
+	}
+
+	logWarn("%d posts have warnings:", count)
+	for _, post := range posts {
+		if len(post.Warnings) == 0 {
+			continue
+		}
+		logWarn("  [%s] %s", post.ID, post.Title)
+		for _, warning := range post.Warnings {
+			logWarn("    - %s", warning)
+		}
+	}
+}
+
+// logAuthorMapping prints the author slug each WordPress login resolves to
+func logAuthorMapping(resolver *authors.Resolver, exportAuthors []models.Author) {
+	if !cfg.Verbose {
+		return
+	}
+
+	logVerbose("👤 Author mapping:")
+	for _, author := range exportAuthors {
+		res := resolver.Lookup(author.Login)
+		note := ""
+		switch {
+		case res.Resolved():
+			note = " (" + res.Rule + ")"
+		case res.Source == "stub":
+			note = " (no entry, stub)"
+		default:
+			note = " (no entry)"
+		}
+		logVerbose("   %-20s → %s%s", author.Login, res.Slug, note)
+	}
+}
+
+// reportAuthors lists the authors of converted posts that have no entry in
+// the authors collection and writes stub entries for them
+func reportAuthors(resolver *authors.Resolver) error {
+	unresolved := resolver.Unresolved()
+	if len(unresolved) == 0 {
+		return nil
+	}
+
+	logInfo("   Unresolved authors: %d", len(unresolved))
+	for _, res := range unresolved {
+		logInfo("     %-20s → %s (%s)", res.Login, res.Slug, res.Name)
+	}
+
+	if cfg.DryRun || resolver.Dir() == "" {
+		return nil
+	}
+
+	written, err := resolver.WriteStubs()
+	for _, filename := range written {
+		logInfo("👤 Created author stub %s", filename)
+	}
+	return err
+}
+
+// reportGlossary lists the glossary terms linked in each post
+func reportGlossary(posts []*models.Post) {
+	total, count := 0, 0
+	for _, post := range posts {
+		if len(post.GlossaryTerms) > 0 {
+			total += len(post.GlossaryTerms)
+			count++
+		}
+	}
+
+	logInfo("   Glossary terms linked: %d in %d posts", total, count)
+	for _, post := range posts {
+		if len(post.GlossaryTerms) > 0 {
+			logInfo("     [%s] %s: %s", post.ID, post.Title, strings.Join(post.GlossaryTerms, ", "))
+		}
+	}
+}
+
+// reportReferences counts the citations linked to existing references,
+// writes the merge report if configured and writes entries for the new ones
+func reportReferences(library *references.Library) error {
+	linked, created := library.Linked(), library.Created()
+	if len(linked) == 0 && len(created) == 0 {
+		return nil
+	}
+
+	logInfo("   References linked: %d", len(linked))
+	logInfo("   References created: %d", len(created))
+	for _, ref := range created {
+		logVerbose("     %s", ref.ID)
+	}
+
+	if cfg.ReferenceReport != "" {
+		if err := writeReferenceReport(library, cfg.ReferenceReport); err != nil {
+			return err
+		}
+		logInfo("📚 Wrote reference report %s", cfg.ReferenceReport)
+	}
+
+	if cfg.DryRun || library.Dir() == "" {
+		return nil
+	}
+
+	written, err := library.WriteNew()
+	for _, filename := range written {
+		logInfo("📚 Created reference %s", filename)
 	}
+	return err
+}
+
+// formatBytes renders a byte count with a binary unit
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
 }
 
 func logInfo(format string, args ...interface{}) {
@@ -325,6 +997,12 @@ func logInfo(format string, args ...interface{}) {
 	}
 }
 
+func logVerbose(format string, args ...interface{}) {
+	if cfg.Verbose && !cfg.Quiet {
+		fmt.Printf(format+"\n", args...)
+	}
+}
+
 func logWarn(format string, args ...interface{}) {
 	if !cfg.Quiet {
 		fmt.Fprintf(os.Stderr, "⚠️  "+format+"\n", args...)
@@ -334,4 +1012,3 @@ func logWarn(format string, args ...interface{}) {
 func logError(format string, args ...interface{}) {
 	fmt.Fprintf(os.Stderr, "❌ "+format+"\n", args...)
 }
-
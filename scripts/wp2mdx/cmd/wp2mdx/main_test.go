@@ -0,0 +1,33 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestResolveConfigKeepsFlags(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "wp2mdx.yaml")
+	if err := os.WriteFile(configPath, []byte("input: from-config.xml\ninclude-drafts: true\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	args := []string{"--config", configPath, "--format", "netlify", "--input", "from-flag.xml"}
+	if err := redirectsCmd.ParseFlags(args); err != nil {
+		t.Fatalf("ParseFlags() error = %v", err)
+	}
+	if err := resolveConfig(redirectsCmd); err != nil {
+		t.Fatalf("resolveConfig() error = %v", err)
+	}
+
+	if want := []string{"netlify"}; !reflect.DeepEqual(redirectFormats, want) {
+		t.Errorf("redirect formats = %q, want %q", redirectFormats, want)
+	}
+	if cfg.InputFile != "from-flag.xml" {
+		t.Errorf("input = %q, the flag should win over the config file", cfg.InputFile)
+	}
+	if !cfg.IncludeDrafts {
+		t.Errorf("include drafts should be read from the config file")
+	}
+}
@@ -0,0 +1,332 @@
+// Package authors resolves WordPress authors to entries of the site's
+// authors collection (src/data/authors/<slug>.md) and creates stub entries
+// for authors that have none.
+package authors
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/aequinox/gesundes-leben/wp2mdx/pkg/models"
+	"github.com/aequinox/gesundes-leben/wp2mdx/pkg/parser"
+	"gopkg.in/yaml.v3"
+)
+
+// Entry is an author in the authors collection
+type Entry struct {
+	Slug string
+	Name string
+}
+
+// Resolution records how a WordPress login was resolved
+type Resolution struct {
+	Login string
+	Slug  string
+	Name  string
+	// Source is "mapping", "match", "stub" or "missing"
+	Source string
+	// Rule describes the match, e.g. `name "Kai Renner"`
+	Rule string
+}
+
+// Resolved reports whether the login maps to an existing author entry
+func (r Resolution) Resolved() bool {
+	return r.Source == "mapping" || r.Source == "match"
+}
+
+// Resolver maps WordPress logins to author slugs. Explicit mappings win;
+// otherwise the export's author names are matched against the collection.
+type Resolver struct {
+	dir     string
+	entries []Entry
+	mapping map[string]string
+	authors map[string]models.Author
+
+	mu       sync.Mutex
+	resolved map[string]Resolution
+}
+
+// Load reads the author entries in dir. A missing directory yields a
+// resolver that only applies mappings and never writes stubs.
+func Load(dir string, mapping map[string]string, authors []models.Author) (*Resolver, error) {
+	r := &Resolver{
+		mapping:  mapping,
+		authors:  make(map[string]models.Author),
+		resolved: make(map[string]Resolution),
+	}
+	for _, author := range authors {
+		r.authors[author.Login] = author
+	}
+
+	if dir == "" {
+		return r, nil
+	}
+	if _, err := os.Stat(dir); err != nil {
+		if os.IsNotExist(err) {
+			return r, nil
+		}
+		return nil, fmt.Errorf("failed to read authors directory: %w", err)
+	}
+	r.dir = dir
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.md"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list authors: %w", err)
+	}
+
+	sort.Strings(files)
+	for _, file := range files {
+		entry, err := readEntry(file)
+		if err != nil {
+			return nil, err
+		}
+		r.entries = append(r.entries, entry)
+	}
+
+	return r, nil
+}
+
+// Dir returns the authors directory, or "" if it does not exist
+func (r *Resolver) Dir() string {
+	return r.dir
+}
+
+// Entries returns the author entries found in the directory
+func (r *Resolver) Entries() []Entry {
+	return r.entries
+}
+
+// readEntry reads the slug and name of an author file
+func readEntry(filename string) (Entry, error) {
+	entry := Entry{Slug: strings.TrimSuffix(filepath.Base(filename), filepath.Ext(filename))}
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return entry, fmt.Errorf("failed to read author %s: %w", filename, err)
+	}
+
+	data = bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n"))
+	if !bytes.HasPrefix(data, []byte("---\n")) {
+		return entry, nil
+	}
+	end := bytes.Index(data[4:], []byte("\n---"))
+	if end == -1 {
+		return entry, nil
+	}
+
+	var fm struct {
+		Name string `yaml:"name"`
+	}
+	if err := yaml.Unmarshal(data[4:4+end], &fm); err != nil {
+		return entry, fmt.Errorf("failed to parse author %s: %w", filename, err)
+	}
+	entry.Name = fm.Name
+
+	return entry, nil
+}
+
+// Lookup returns how a login would be resolved without recording it, so it
+// does not lead to a stub being written
+func (r *Resolver) Lookup(login string) Resolution {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if res, ok := r.resolved[login]; ok {
+		return res
+	}
+	return r.resolve(login)
+}
+
+// Resolve returns the author slug for a WordPress login. Logins without an
+// entry get the slug of a stub to be created by WriteStubs.
+func (r *Resolver) Resolve(login string) Resolution {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if res, ok := r.resolved[login]; ok {
+		return res
+	}
+	res := r.resolve(login)
+	r.resolved[login] = res
+	return res
+}
+
+// resolve applies the mapping, then name matching, then falls back to a stub
+func (r *Resolver) resolve(login string) Resolution {
+	author := r.authors[login]
+	name := displayName(author, login)
+
+	if slug, ok := r.mapping[login]; ok {
+		res := Resolution{Login: login, Slug: slug, Name: name, Source: "mapping", Rule: "mapping file"}
+		if r.dir != "" && r.entry(slug) == nil {
+			// Mapped to an author that does not exist yet
+			res.Source = "stub"
+		}
+		return res
+	}
+
+	if entry, rule := r.match(author, login); entry != nil {
+		return Resolution{Login: login, Slug: entry.Slug, Name: entry.Name, Source: "match", Rule: rule}
+	}
+
+	res := Resolution{Login: login, Slug: parser.GenerateSlug(name), Name: name, Source: "stub"}
+	if res.Slug == "" {
+		res.Slug = parser.GenerateSlug(login)
+	}
+	if r.dir == "" {
+		res.Source = "missing"
+	}
+	return res
+}
+
+// match finds the entry for an export author: by full name, by slug, by
+// login in the form first initial plus last name ("KRenner"), and finally,
+// for authors known by a first name only, by a first name that only one
+// entry has
+func (r *Resolver) match(author models.Author, login string) (*Entry, string) {
+	fullName := strings.TrimSpace(author.FirstName + " " + author.LastName)
+
+	for _, name := range []string{fullName, author.DisplayName} {
+		if name == "" {
+			continue
+		}
+		slug := parser.GenerateSlug(name)
+		for i := range r.entries {
+			entry := &r.entries[i]
+			if entry.Slug == slug || parser.GenerateSlug(entry.Name) == slug {
+				return entry, fmt.Sprintf("name %q", name)
+			}
+		}
+	}
+
+	loginKey := parser.GenerateSlug(login)
+	for i := range r.entries {
+		entry := &r.entries[i]
+		if loginKey == entry.Slug {
+			return entry, fmt.Sprintf("login %q", login)
+		}
+		first, last := splitName(entry.Name)
+		if first != "" && last != "" && loginKey == parser.GenerateSlug(string([]rune(first)[:1])+last) {
+			return entry, fmt.Sprintf("login %q", login)
+		}
+	}
+
+	// An author with a different last name is someone else, e.g. "Kai
+	// Müller" is not "Kai Renner"
+	if author.LastName != "" || strings.Contains(strings.TrimSpace(author.DisplayName), " ") {
+		return nil, ""
+	}
+	firstName := author.FirstName
+	if firstName == "" {
+		firstName = strings.TrimSpace(author.DisplayName)
+	}
+	if firstName != "" {
+		var found *Entry
+		for i := range r.entries {
+			first, _ := splitName(r.entries[i].Name)
+			if strings.EqualFold(first, firstName) {
+				if found != nil {
+					return nil, ""
+				}
+				found = &r.entries[i]
+			}
+		}
+		if found != nil {
+			return found, fmt.Sprintf("first name %q", firstName)
+		}
+	}
+
+	return nil, ""
+}
+
+// entry returns the entry with the given slug
+func (r *Resolver) entry(slug string) *Entry {
+	for i := range r.entries {
+		if r.entries[i].Slug == slug {
+			return &r.entries[i]
+		}
+	}
+	return nil
+}
+
+// Unresolved returns the logins seen so far that have no author entry,
+// sorted by login
+func (r *Resolver) Unresolved() []Resolution {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var unresolved []Resolution
+	for _, res := range r.resolved {
+		if !res.Resolved() {
+			unresolved = append(unresolved, res)
+		}
+	}
+	sort.Slice(unresolved, func(i, j int) bool {
+		return unresolved[i].Login < unresolved[j].Login
+	})
+	return unresolved
+}
+
+// WriteStubs creates an entry with name and bio placeholders for every
+// unresolved author. Existing files are never overwritten. It returns the
+// paths of the files written.
+func (r *Resolver) WriteStubs() ([]string, error) {
+	if r.dir == "" {
+		return nil, nil
+	}
+
+	var written []string
+	seen := make(map[string]bool)
+	for _, res := range r.Unresolved() {
+		if seen[res.Slug] {
+			continue
+		}
+		seen[res.Slug] = true
+
+		filename := filepath.Join(r.dir, res.Slug+".md")
+		if _, err := os.Stat(filename); err == nil {
+			continue
+		}
+		if err := os.WriteFile(filename, []byte(stubContent(res)), 0644); err != nil {
+			return written, fmt.Errorf("failed to write author stub: %w", err)
+		}
+		written = append(written, filename)
+	}
+
+	return written, nil
+}
+
+// stubContent renders a placeholder author entry
+func stubContent(res Resolution) string {
+	name, _ := yaml.Marshal(res.Name)
+	return fmt.Sprintf(`---
+name: %s
+bio: "TODO: Kurzbiografie von %s ergänzen"
+---
+`, strings.TrimSpace(string(name)), strings.ReplaceAll(res.Name, `"`, `'`))
+}
+
+// displayName returns the best human-readable name of an export author
+func displayName(author models.Author, login string) string {
+	if name := strings.TrimSpace(author.FirstName + " " + author.LastName); name != "" {
+		return name
+	}
+	if name := strings.TrimSpace(author.DisplayName); name != "" {
+		return name
+	}
+	return login
+}
+
+// splitName splits a name into first name and the remaining last name
+func splitName(name string) (first, last string) {
+	fields := strings.Fields(name)
+	if len(fields) == 0 {
+		return "", ""
+	}
+	return fields[0], strings.Join(fields[1:], " ")
+}
@@ -0,0 +1,51 @@
+package authors
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aequinox/gesundes-leben/wp2mdx/pkg/models"
+)
+
+func TestResolve(t *testing.T) {
+	dir := t.TempDir()
+	for slug, name := range map[string]string{"kai-renner": "Kai Renner", "sandra-pfeiffer": "Sandra Pfeiffer"} {
+		if err := os.WriteFile(filepath.Join(dir, slug+".md"), []byte("---\nname: "+name+"\n---\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	exportAuthors := []models.Author{
+		{Login: "admin", DisplayName: "Kai Renner"},
+		{Login: "SPfeiffer"},
+		{Login: "kai", FirstName: "Kai"},
+		{Login: "sandra", DisplayName: "Sandra"},
+		{Login: "kmueller", FirstName: "Kai", LastName: "Müller"},
+		{Login: "kai.m", FirstName: "Kai", DisplayName: "Kai Müller"},
+	}
+	r, err := Load(dir, map[string]string{"editor": "sandra-pfeiffer"}, exportAuthors)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	tests := []struct {
+		login  string
+		slug   string
+		source string
+	}{
+		{"editor", "sandra-pfeiffer", "mapping"},
+		{"admin", "kai-renner", "match"},
+		{"SPfeiffer", "sandra-pfeiffer", "match"},
+		{"kai", "kai-renner", "match"},
+		{"sandra", "sandra-pfeiffer", "match"},
+		{"kmueller", "kai-mueller", "stub"},
+		{"kai.m", "kai", "stub"},
+	}
+	for _, tt := range tests {
+		res := r.Resolve(tt.login)
+		if res.Slug != tt.slug || res.Source != tt.source {
+			t.Errorf("Resolve(%q) = %s (%s), want %s (%s)", tt.login, res.Slug, res.Source, tt.slug, tt.source)
+		}
+	}
+}
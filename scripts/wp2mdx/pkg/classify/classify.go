@@ -0,0 +1,172 @@
+// Package classify assigns blog posts to one of the site's groups (pro,
+// kontra, fragezeiten) using weighted keyword rules and explicit overrides.
+package classify
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/aequinox/gesundes-leben/wp2mdx/pkg/keywords"
+)
+
+// maxBodyHits caps how often a term counts in the post body, so a single
+// word repeated throughout a long post cannot decide the group alone
+const maxBodyHits = 3
+
+// Input is the part of a post the classifier looks at
+type Input struct {
+	ID         int
+	Title      string
+	Content    string // HTML
+	Categories []string
+	Tags       []string
+}
+
+// Result is the group chosen for a post. Confidence is 1 for overrides and
+// the winning group's share of all term scores otherwise; Rule describes
+// what decided the group.
+type Result struct {
+	Group      string
+	Confidence float64
+	Rule       string
+	Scores     map[string]float64
+}
+
+// Classifier applies a set of rules to posts
+type Classifier struct {
+	rules      *Rules
+	categories map[string]string
+	tags       map[string]string
+}
+
+// New creates a classifier for the given rules
+func New(rules *Rules) *Classifier {
+	return &Classifier{
+		rules:      rules,
+		categories: lowerKeys(rules.Categories),
+		tags:       lowerKeys(rules.Tags),
+	}
+}
+
+// Classify determines the group of a post
+func (c *Classifier) Classify(in Input) Result {
+	if group, ok := c.rules.Posts[fmt.Sprint(in.ID)]; ok {
+		return Result{Group: group, Confidence: 1, Rule: fmt.Sprintf("post %d", in.ID)}
+	}
+	for _, tag := range in.Tags {
+		if group, ok := c.tags[strings.ToLower(strings.TrimSpace(tag))]; ok {
+			return Result{Group: group, Confidence: 1, Rule: "tag " + tag}
+		}
+	}
+	for _, category := range in.Categories {
+		if group, ok := c.categories[strings.ToLower(strings.TrimSpace(category))]; ok {
+			return Result{Group: group, Confidence: 1, Rule: "category " + category}
+		}
+	}
+
+	return c.score(in)
+}
+
+// score picks the group whose terms weigh most in the title and body
+func (c *Classifier) score(in Input) Result {
+	title := strings.ToLower(in.Title)
+	body := strings.ToLower(keywords.PlainText(in.Content))
+	titleWords, bodyWords := words(title), words(body)
+
+	result := Result{Scores: make(map[string]float64)}
+	matches := make(map[string][]string)
+	total := 0.0
+
+	for _, group := range sortedKeys(c.rules.Groups) {
+		for _, term := range c.rules.Groups[group] {
+			pattern := strings.ToLower(strings.TrimSpace(term.Term))
+
+			score := 0.0
+			if count(pattern, title, titleWords) > 0 {
+				score += term.Weight * c.rules.TitleBoost
+			}
+			if !term.TitleOnly {
+				hits := count(pattern, body, bodyWords)
+				if hits > maxBodyHits {
+					hits = maxBodyHits
+				}
+				score += term.Weight * float64(hits)
+			}
+
+			if score > 0 {
+				result.Scores[group] += score
+				total += score
+				matches[group] = append(matches[group], pattern)
+			}
+		}
+	}
+
+	best := ""
+	for _, group := range sortedKeys(result.Scores) {
+		if best == "" || result.Scores[group] > result.Scores[best] {
+			best = group
+		}
+	}
+
+	if best == "" || result.Scores[best] < c.rules.MinScore {
+		result.Group = c.rules.Default
+		result.Rule = "default"
+		return result
+	}
+
+	result.Group = best
+	result.Confidence = result.Scores[best] / total
+	result.Rule = "terms " + strings.Join(matches[best], ", ")
+	return result
+}
+
+// count returns how often pattern occurs in text. Word patterns match at
+// word starts, patterns with other characters anywhere.
+func count(pattern, text string, textWords []string) int {
+	patternWords := words(pattern)
+	if strings.Join(patternWords, " ") != pattern {
+		return strings.Count(text, pattern)
+	}
+
+	n := 0
+	for i := 0; i+len(patternWords) <= len(textWords); i++ {
+		match := true
+		for j, w := range patternWords {
+			if !strings.HasPrefix(textWords[i+j], w) {
+				match = false
+				break
+			}
+		}
+		if match {
+			n++
+		}
+	}
+	return n
+}
+
+// words splits lowercase text into words
+func words(text string) []string {
+	return strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// lowerKeys returns a copy of m with lowercase keys
+func lowerKeys(m map[string]string) map[string]string {
+	result := make(map[string]string, len(m))
+	for k, v := range m {
+		result[strings.ToLower(strings.TrimSpace(k))] = v
+	}
+	return result
+}
+
+// SortedGroups returns the groups of a result's scores, highest first
+func (r Result) SortedGroups() []string {
+	groups := sortedKeys(r.Scores)
+	sort.SliceStable(groups, func(i, j int) bool {
+		return r.Scores[groups[i]] > r.Scores[groups[j]]
+	})
+	return groups
+}
@@ -0,0 +1,156 @@
+package classify
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Rules configure the group classifier. Overrides are checked first, in the
+// order post ID, tag, category; otherwise the group whose weighted terms
+// score highest wins.
+type Rules struct {
+	// Default is used when no override applies and no group reaches MinScore
+	Default string `json:"default"`
+	// MinScore is the score a group needs to be chosen by its terms
+	MinScore float64 `json:"minScore"`
+	// TitleBoost multiplies the weight of terms found in the title
+	TitleBoost float64 `json:"titleBoost"`
+	// Groups maps each group to the terms that indicate it
+	Groups map[string][]Term `json:"groups"`
+	// Categories and Tags map WordPress category and tag names
+	// (case-insensitive) to a group
+	Categories map[string]string `json:"categories"`
+	Tags       map[string]string `json:"tags"`
+	// Posts maps WordPress post IDs to a group
+	Posts map[string]string `json:"posts"`
+}
+
+// Term is a weighted indicator of a group. Words match at the start of a
+// word, so "gefahr" also matches "Gefahren" and "gefährlich" does not;
+// terms with punctuation such as "?" match anywhere.
+type Term struct {
+	Term   string  `json:"term"`
+	Weight float64 `json:"weight"`
+	// TitleOnly restricts the term to the post title
+	TitleOnly bool `json:"titleOnly,omitempty"`
+}
+
+// DefaultRules returns the built-in rules: warnings and risks indicate
+// "kontra", questions "fragezeiten", and everything else is "pro"
+func DefaultRules() *Rules {
+	return &Rules{
+		Default:    "pro",
+		MinScore:   2,
+		TitleBoost: 3,
+		Groups: map[string][]Term{
+			"kontra": {
+				{Term: "gefahr", Weight: 2},
+				{Term: "gefährlich", Weight: 2},
+				{Term: "risiko", Weight: 2},
+				{Term: "risiken", Weight: 2},
+				{Term: "warnung", Weight: 2},
+				{Term: "vorsicht", Weight: 1.5},
+				{Term: "nachteil", Weight: 1.5},
+				{Term: "schaden", Weight: 1},
+				{Term: "schädlich", Weight: 1.5},
+				{Term: "giftig", Weight: 1.5},
+				{Term: "problem", Weight: 0.5},
+			},
+			"fragezeiten": {
+				{Term: "?", Weight: 2, TitleOnly: true},
+				{Term: "frage", Weight: 1.5, TitleOnly: true},
+				{Term: "warum", Weight: 1.5, TitleOnly: true},
+				{Term: "mythos", Weight: 2},
+				{Term: "mythen", Weight: 2},
+				{Term: "wahrheit", Weight: 1},
+			},
+			"pro": {
+				{Term: "vorteil", Weight: 1.5},
+				{Term: "tipps", Weight: 1},
+				{Term: "stärken", Weight: 1},
+				{Term: "unterstützen", Weight: 0.5},
+				{Term: "wirkung", Weight: 0.5},
+			},
+		},
+		Categories: map[string]string{},
+		Tags:       map[string]string{},
+		Posts:      map[string]string{},
+	}
+}
+
+// Load reads classification rules from a JSON file. An empty filename
+// returns the default rules; fields missing from the file keep their
+// defaults, and groups listed in the file replace the default terms of
+// that group.
+func Load(filename string) (*Rules, error) {
+	r := DefaultRules()
+	if filename == "" {
+		return r, nil
+	}
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read group rules: %w", err)
+	}
+
+	if err := json.Unmarshal(data, r); err != nil {
+		return nil, fmt.Errorf("failed to parse group rules: %w", err)
+	}
+
+	return r, nil
+}
+
+// Validate checks that the rules only refer to the given groups and have
+// usable weights
+func (r *Rules) Validate(groups []string) error {
+	valid := make(map[string]bool, len(groups))
+	for _, g := range groups {
+		valid[g] = true
+	}
+
+	var problems []string
+	check := func(where, group string) {
+		if !valid[group] {
+			problems = append(problems, fmt.Sprintf("%s: unknown group %q", where, group))
+		}
+	}
+
+	check("default", r.Default)
+	for _, group := range sortedKeys(r.Groups) {
+		check("groups", group)
+		for _, term := range r.Groups[group] {
+			if strings.TrimSpace(term.Term) == "" {
+				problems = append(problems, fmt.Sprintf("groups.%s: empty term", group))
+			}
+			if term.Weight <= 0 {
+				problems = append(problems, fmt.Sprintf("groups.%s: term %q needs a positive weight", group, term.Term))
+			}
+		}
+	}
+	for _, overrides := range []struct {
+		name   string
+		values map[string]string
+	}{{"categories", r.Categories}, {"tags", r.Tags}, {"posts", r.Posts}} {
+		for _, key := range sortedKeys(overrides.values) {
+			check(overrides.name+"."+key, overrides.values[key])
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid group rules: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// sortedKeys returns the keys of a map in ascending order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
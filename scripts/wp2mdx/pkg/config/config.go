@@ -4,45 +4,76 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"strings"
 	"time"
 )
 
-// Config holds all configuration options for the converter
+// Config holds all configuration options for the converter. The yaml and
+// toml keys match the command-line flag names and are used by project config
+// files.
 type Config struct {
 	// Input/Output
-	InputFile  string
-	OutputDir  string
+	InputFile string `yaml:"input" toml:"input"`
+	OutputDir string `yaml:"output" toml:"output"`
 
 	// Organization
-	YearFolders   bool
-	MonthFolders  bool
-	PostFolders   bool
-	PrefixDate    bool
+	YearFolders  bool `yaml:"year-folders" toml:"year-folders"`
+	MonthFolders bool `yaml:"month-folders" toml:"month-folders"`
+	PostFolders  bool `yaml:"post-folders" toml:"post-folders"`
+	PrefixDate   bool `yaml:"prefix-date" toml:"prefix-date"`
 
 	// Image Processing
-	DownloadImages   bool
-	DownloadAttached bool
-	DownloadScraped  bool
-	ImageQuality     int
-	MaxImageWidth    int
-	ImageBaseURL     string
+	DownloadImages   bool   `yaml:"download-images" toml:"download-images"`
+	DownloadAttached bool   `yaml:"download-attached" toml:"download-attached"`
+	DownloadScraped  bool   `yaml:"download-scraped" toml:"download-scraped"`
+	ImageQuality     int    `yaml:"image-quality" toml:"image-quality"`
+	MaxImageWidth    int    `yaml:"max-image-width" toml:"max-image-width"`
+	ImageBaseURL     string `yaml:"image-base-url" toml:"image-base-url"`
+	ImageCacheDir    string `yaml:"image-cache" toml:"image-cache"`
 
 	// Processing
-	Concurrency    int
-	IncludeDrafts  bool
-	IncludePages   bool
-	IncludeTypes   bool
+	Concurrency   int    `yaml:"concurrency" toml:"concurrency"`
+	IncludeDrafts bool   `yaml:"include-drafts" toml:"include-drafts"`
+	IncludePages  bool   `yaml:"include-pages" toml:"include-pages"`
+	IncludeTypes  bool   `yaml:"include-types" toml:"include-types"`
+	Timezone      string `yaml:"timezone" toml:"timezone"`
+
+	// Reading Time
+	ReadingWPM          int `yaml:"reading-wpm" toml:"reading-wpm"`
+	ReadingImageSeconds int `yaml:"reading-image-seconds" toml:"reading-image-seconds"`
+
+	// Links
+	RewriteLinks  bool `yaml:"rewrite-links" toml:"rewrite-links"`
+	LinkComponent bool `yaml:"link-component" toml:"link-component"`
+
+	// References
+	ExtractReferences  bool    `yaml:"extract-references" toml:"extract-references"`
+	ReferencesDir      string  `yaml:"references-dir" toml:"references-dir"`
+	ReferenceThreshold float64 `yaml:"reference-threshold" toml:"reference-threshold"`
+	ReferenceReport    string  `yaml:"reference-report" toml:"reference-report"`
+
+	// Glossary
+	LinkGlossary bool   `yaml:"link-glossary" toml:"link-glossary"`
+	GlossaryDir  string `yaml:"glossary-dir" toml:"glossary-dir"`
 
 	// Output Control
-	DryRun  bool
-	Verbose bool
-	Quiet   bool
-	Force   bool
+	DryRun  bool `yaml:"dry-run" toml:"dry-run"`
+	Verbose bool `yaml:"verbose" toml:"verbose"`
+	Quiet   bool `yaml:"quiet" toml:"quiet"`
+	Force   bool `yaml:"force" toml:"force"`
+
+	// Validation
+	SchemaFile   string `yaml:"schema" toml:"schema"`
+	GroupRules   string `yaml:"group-rules" toml:"group-rules"`
+	StrictSchema bool   `yaml:"strict-schema" toml:"strict-schema"`
 
 	// Advanced
-	AuthorMapping   map[string]string
-	CategoryMapping map[string]string
-	Timeout         time.Duration
+	AuthorMapping       map[string]string `yaml:"authors" toml:"authors"`
+	CategoryMapping     map[string]string `yaml:"categories" toml:"categories"`
+	AuthorMappingFile   string            `yaml:"author-mapping" toml:"author-mapping"`
+	AuthorsDir          string            `yaml:"authors-dir" toml:"authors-dir"`
+	CategoryMappingFile string            `yaml:"category-mapping" toml:"category-mapping"`
+	Timeout             time.Duration     `yaml:"timeout" toml:"timeout"`
 }
 
 // DefaultConfig returns configuration with sensible defaults
@@ -62,13 +93,23 @@ func DefaultConfig() *Config {
 		IncludeDrafts:    false,
 		IncludePages:     false,
 		IncludeTypes:     false,
+		Timezone:         "Europe/Berlin",
 		DryRun:           false,
 		Verbose:          false,
 		Quiet:            false,
 		Force:            false,
 		Timeout:          30 * time.Second,
 		AuthorMapping:    make(map[string]string),
+		AuthorsDir:       "../../src/data/authors",
 		CategoryMapping:  getDefaultCategoryMapping(),
+
+		ReadingWPM:          180,
+		ReadingImageSeconds: 12,
+		RewriteLinks:        true,
+		ExtractReferences:   true,
+		ReferencesDir:       "../../src/data/references",
+		ReferenceThreshold:  0.85,
+		GlossaryDir:         "../../src/data/glossary",
 	}
 }
 
@@ -127,6 +168,40 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("max image width must be at least 100")
 	}
 
+	if c.Timeout <= 0 {
+		return fmt.Errorf("timeout must be positive")
+	}
+
+	if _, err := time.LoadLocation(c.Timezone); err != nil {
+		return fmt.Errorf("invalid timezone %q: %w", c.Timezone, err)
+	}
+
+	if c.ReadingWPM < 1 {
+		return fmt.Errorf("reading speed must be at least 1 word per minute")
+	}
+
+	if c.ReadingImageSeconds < 0 {
+		return fmt.Errorf("reading time per image must not be negative")
+	}
+
+	if c.ReferenceThreshold <= 0 || c.ReferenceThreshold > 1 {
+		return fmt.Errorf("reference threshold must be greater than 0 and at most 1")
+	}
+
+	return nil
+}
+
+// Resolve loads the mapping files referenced by the configuration. A mapping
+// file that is set but missing or malformed is an error.
+func (c *Config) Resolve() error {
+	if err := c.LoadAuthorMapping(c.AuthorMappingFile); err != nil {
+		return err
+	}
+
+	if err := c.LoadCategoryMapping(c.CategoryMappingFile); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -146,7 +221,15 @@ func (c *Config) LoadAuthorMapping(filename string) error {
 		return fmt.Errorf("failed to parse author mapping: %w", err)
 	}
 
-	c.AuthorMapping = mapping
+	if err := validateMapping(mapping); err != nil {
+		return fmt.Errorf("invalid author mapping %s: %w", filename, err)
+	}
+
+	// Merge over mappings from the config file
+	for k, v := range mapping {
+		c.AuthorMapping[k] = v
+	}
+
 	return nil
 }
 
@@ -166,14 +249,46 @@ func (c *Config) LoadCategoryMapping(filename string) error {
 		return fmt.Errorf("failed to parse category mapping: %w", err)
 	}
 
-	// Merge with defaults
+	if err := validateMapping(mapping); err != nil {
+		return fmt.Errorf("invalid category mapping %s: %w", filename, err)
+	}
+
+	// Merge with defaults; categories are looked up lowercased
 	for k, v := range mapping {
-		c.CategoryMapping[k] = v
+		c.CategoryMapping[strings.ToLower(strings.TrimSpace(k))] = v
 	}
 
 	return nil
 }
 
+// validateMapping rejects mappings with empty keys or values
+func validateMapping(mapping map[string]string) error {
+	if len(mapping) == 0 {
+		return fmt.Errorf("mapping is empty")
+	}
+
+	for k, v := range mapping {
+		if strings.TrimSpace(k) == "" {
+			return fmt.Errorf("empty key mapped to %q", v)
+		}
+		if strings.TrimSpace(v) == "" {
+			return fmt.Errorf("empty value for key %q", k)
+		}
+	}
+
+	return nil
+}
+
+// Location returns the site timezone WordPress local times are in. An
+// invalid timezone falls back to UTC; Validate reports it.
+func (c *Config) Location() *time.Location {
+	loc, err := time.LoadLocation(c.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
 // GetAuthor returns the mapped author name or the original if no mapping exists
 func (c *Config) GetAuthor(original string) string {
 	if mapped, ok := c.AuthorMapping[original]; ok {
@@ -0,0 +1,259 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// LoadFile merges a YAML or TOML project config file into the configuration.
+// Keys missing from the file keep their current values; category mappings are
+// merged with the defaults.
+func (c *Config) LoadFile(filename string) error {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".toml":
+		err = c.decodeTOML(data)
+	case ".yaml", ".yml", ".json":
+		err = c.decodeYAML(data)
+	default:
+		return fmt.Errorf("unsupported config file format: %s", filename)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to parse config file %s: %w", filename, err)
+	}
+
+	c.normalize()
+	return nil
+}
+
+// decodeYAML decodes a YAML (or JSON) config file; unknown keys are errors
+func (c *Config) decodeYAML(data []byte) error {
+	data, err := normalizeTimeout(data)
+	if err != nil {
+		return err
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	return nil
+}
+
+// decodeTOML decodes a TOML config file; unknown keys are errors. As with
+// YAML, an integer timeout is in seconds.
+func (c *Config) decodeTOML(data []byte) error {
+	meta, err := toml.Decode(string(data), c)
+	if err != nil {
+		return err
+	}
+
+	if undecoded := meta.Undecoded(); len(undecoded) > 0 {
+		return fmt.Errorf("unknown key %q", undecoded[0].String())
+	}
+
+	if meta.Type("timeout") == "Integer" {
+		c.Timeout *= time.Second
+	}
+	return nil
+}
+
+// normalizeTimeout rewrites a bare integer timeout to seconds, matching the
+// --timeout flag; duration strings such as "1m30s" are left untouched
+func normalizeTimeout(data []byte) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return data, nil
+	}
+
+	mapping := doc.Content[0]
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		key, value := mapping.Content[i], mapping.Content[i+1]
+		if key.Value == "timeout" && value.Tag == "!!int" {
+			value.Value += "s"
+			value.Tag = "!!str"
+			return yaml.Marshal(&doc)
+		}
+	}
+
+	return data, nil
+}
+
+// normalize restores invariants after values were decoded from a file
+func (c *Config) normalize() {
+	if c.AuthorMapping == nil {
+		c.AuthorMapping = make(map[string]string)
+	}
+
+	categories := getDefaultCategoryMapping()
+	for k, v := range c.CategoryMapping {
+		categories[strings.ToLower(strings.TrimSpace(k))] = v
+	}
+	c.CategoryMapping = categories
+}
+
+// ToYAML renders the configuration as YAML, e.g. for "config show"
+func (c *Config) ToYAML() (string, error) {
+	data, err := yaml.Marshal(c)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal config: %w", err)
+	}
+	return string(data), nil
+}
+
+// WriteDefaultFile writes a commented config file with the default values
+func WriteDefaultFile(filename string, force bool) error {
+	if _, err := os.Stat(filename); err == nil && !force {
+		return fmt.Errorf("config file already exists (use --force to overwrite): %s", filename)
+	}
+
+	content, err := DefaultFileContent()
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+
+	return nil
+}
+
+// DefaultFileContent renders the commented default config file
+func DefaultFileContent() (string, error) {
+	defaults := DefaultConfig()
+
+	keys := make([]string, 0, len(defaults.CategoryMapping))
+	for k := range defaults.CategoryMapping {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var sb strings.Builder
+	err := defaultFileTemplate.Execute(&sb, struct {
+		*Config
+		CategoryKeys []string
+	}{defaults, keys})
+	if err != nil {
+		return "", fmt.Errorf("failed to render config file: %w", err)
+	}
+
+	return sb.String(), nil
+}
+
+var defaultFileTemplate = template.Must(template.New("config").Funcs(template.FuncMap{
+	"quote": func(s string) string { return fmt.Sprintf("%q", s) },
+}).Parse(`# wp2mdx project configuration
+#
+# Values here override the built-in defaults; command-line flags override
+# values here. Keys match the flag names of "wp2mdx convert".
+
+# Input/Output
+input: {{ quote .InputFile }}
+output: {{ quote .OutputDir }}
+
+# Organization
+year-folders: {{ .YearFolders }}
+month-folders: {{ .MonthFolders }}   # requires year-folders
+post-folders: {{ .PostFolders }}
+prefix-date: {{ .PrefixDate }}
+
+# Image processing
+download-images: {{ .DownloadImages }}
+download-attached: {{ .DownloadAttached }}
+download-scraped: {{ .DownloadScraped }}
+image-quality: {{ .ImageQuality }}      # 1-100
+max-image-width: {{ .MaxImageWidth }}   # pixels
+image-base-url: {{ quote .ImageBaseURL }}
+image-cache: {{ quote .ImageCacheDir }}   # shared download cache; empty uses the user cache dir
+
+# Filters
+include-drafts: {{ .IncludeDrafts }}
+include-pages: {{ .IncludePages }}
+include-types: {{ .IncludeTypes }}
+
+# Site timezone of WordPress local dates; also written to the frontmatter
+timezone: {{ quote .Timezone }}
+
+# Reading time: words per minute (German prose reads slower than English)
+# and seconds added per image
+reading-wpm: {{ .ReadingWPM }}
+reading-image-seconds: {{ .ReadingImageSeconds }}
+
+# Links between posts of the export are rewritten to their /posts/<slug>/
+# routes; link-component emits <InternalLink> instead of Markdown links
+rewrite-links: {{ .RewriteLinks }}
+link-component: {{ .LinkComponent }}
+
+# Citations in a post's "Quellen" section are linked to entries of the
+# references collection; new entries are created for the others. Matches
+# by title, first author and year need reference-threshold (0-1) to link;
+# reference-report is an optional Markdown file listing every decision.
+extract-references: {{ .ExtractReferences }}
+references-dir: {{ quote .ReferencesDir }}
+reference-threshold: {{ .ReferenceThreshold }}
+reference-report: {{ quote .ReferenceReport }}
+
+# Wrap the first mention of each glossary term (title or synonym, in any
+# inflection) in <GlossaryTooltip>; headings, links and components are skipped
+link-glossary: {{ .LinkGlossary }}
+glossary-dir: {{ quote .GlossaryDir }}
+
+# Processing
+concurrency: {{ .Concurrency }}
+timeout: {{ .Timeout }}
+
+# Output control
+dry-run: {{ .DryRun }}
+force: {{ .Force }}
+verbose: {{ .Verbose }}
+quiet: {{ .Quiet }}
+
+# Frontmatter validation against the Astro blog schema. "schema" is an
+# optional JSON file overriding the built-in categories/groups/constraints;
+# with strict-schema, posts that fail validation are not written.
+schema: {{ quote .SchemaFile }}
+strict-schema: {{ .StrictSchema }}
+
+# Optional JSON file with weighted terms and overrides for the group
+# classifier; "wp2mdx classify" previews the result
+group-rules: {{ quote .GroupRules }}
+
+# WordPress login -> author slug in src/data/authors
+authors: {}
+#  KRenner: kai-renner
+
+# Authors collection; logins without a mapping are matched by name, and a
+# stub entry is created for authors without one
+authors-dir: {{ quote .AuthorsDir }}
+
+# WordPress category (lowercase) -> blog category. Entries are merged with
+# the built-in mapping shown below.
+categories: {}
+{{- range .CategoryKeys }}
+#  {{ quote . }}: {{ quote (index $.CategoryMapping .) }}
+{{- end }}
+
+# Optional JSON mapping files, merged over the maps above
+author-mapping: ""
+category-mapping: ""
+`))
@@ -0,0 +1,127 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestLoadFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+	}{
+		{
+			name: "yaml",
+			file: "wp2mdx.yaml",
+			content: `output: ./posts
+image-quality: 70
+timeout: 45
+authors:
+  KRenner: kai-renner
+categories:
+  " Rezepte ": Ernährung
+`,
+		},
+		{
+			name: "toml",
+			file: "wp2mdx.toml",
+			content: `output = "./posts"
+image-quality = 70
+timeout = 45
+
+[authors]
+KRenner = "kai-renner"
+
+[categories]
+" Rezepte " = "Ernährung"
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filename := writeFile(t, tt.file, tt.content)
+
+			c := DefaultConfig()
+			if err := c.LoadFile(filename); err != nil {
+				t.Fatalf("LoadFile() error = %v", err)
+			}
+
+			if c.OutputDir != "./posts" || c.ImageQuality != 70 {
+				t.Errorf("OutputDir = %q, ImageQuality = %d", c.OutputDir, c.ImageQuality)
+			}
+			if c.Timeout != 45*time.Second {
+				t.Errorf("Timeout = %v, want 45s", c.Timeout)
+			}
+			if c.MaxImageWidth != 2000 || !c.PostFolders {
+				t.Errorf("keys missing from the file should keep their defaults")
+			}
+			if !reflect.DeepEqual(c.AuthorMapping, map[string]string{"KRenner": "kai-renner"}) {
+				t.Errorf("AuthorMapping = %v", c.AuthorMapping)
+			}
+			if c.CategoryMapping["rezepte"] != "Ernährung" || c.CategoryMapping["nutrition"] != "Ernährung" {
+				t.Errorf("categories should be merged with the defaults: %v", c.CategoryMapping)
+			}
+		})
+	}
+}
+
+func TestLoadFileTimeout(t *testing.T) {
+	tests := []struct {
+		file    string
+		content string
+	}{
+		{"duration.yaml", "timeout: 1m30s\n"},
+		{"duration.toml", "timeout = \"1m30s\"\n"},
+		{"seconds.toml", "timeout = 90\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			c := DefaultConfig()
+			if err := c.LoadFile(writeFile(t, tt.file, tt.content)); err != nil {
+				t.Fatalf("LoadFile() error = %v", err)
+			}
+			if c.Timeout != 90*time.Second {
+				t.Errorf("Timeout = %v, want 1m30s", c.Timeout)
+			}
+		})
+	}
+}
+
+func TestLoadFileErrors(t *testing.T) {
+	tests := []struct {
+		file    string
+		content string
+	}{
+		{"unknown.yaml", "image-qualty: 70\n"},
+		{"unknown.toml", "image-qualty = 70\n"},
+		{"unknown-table.toml", "[imges]\nquality = 70\n"},
+		{"type.toml", "image-quality = \"hoch\"\n"},
+		{"syntax.toml", "output = ./posts\n"},
+		{"wp2mdx.ini", "output=./posts\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			c := DefaultConfig()
+			if err := c.LoadFile(writeFile(t, tt.file, tt.content)); err == nil {
+				t.Errorf("LoadFile(%q) should fail", tt.content)
+			}
+		})
+	}
+}
+
+// writeFile writes content to a file in a temporary directory
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	filename := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return filename
+}
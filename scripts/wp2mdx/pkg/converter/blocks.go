@@ -0,0 +1,186 @@
+package converter
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+)
+
+// Block is a node of the Gutenberg block tree. Freeform HTML outside of any
+// block delimiter is represented as a block with an empty name.
+type Block struct {
+	Name        string
+	Attrs       map[string]interface{}
+	InnerBlocks []*Block
+	parts       []blockPart
+}
+
+// blockPart is either a fragment of the block's own HTML or an inner block,
+// preserving the order in which they appear
+type blockPart struct {
+	html  string
+	block *Block
+}
+
+// blockNameRe matches a block name with optional namespace, e.g. "image"
+// or "kadence/rowlayout"
+var blockNameRe = regexp.MustCompile(`^([a-z][a-z0-9_-]*/)?[a-z][a-z0-9_-]*`)
+
+// ParseBlocks parses WordPress block delimiters (<!-- wp:name {attrs} -->)
+// into a block tree. Unbalanced closers are ignored and unclosed blocks
+// extend to the end of the content, mirroring WordPress's own parser.
+func ParseBlocks(content string) []*Block {
+	root := &Block{}
+	stack := []*Block{root}
+	last := 0
+
+	for pos := 0; ; {
+		start := strings.Index(content[pos:], "<!--")
+		if start == -1 {
+			break
+		}
+		start += pos
+
+		end := strings.Index(content[start+4:], "-->")
+		if end == -1 {
+			break
+		}
+		end += start + 4
+		pos = end + 3
+
+		name, attrs, closer, void, ok := parseDelimiter(content[start+4 : end])
+		if !ok {
+			// Ordinary HTML comment; leave it in the surrounding HTML
+			continue
+		}
+
+		top := stack[len(stack)-1]
+		top.appendHTML(content[last:start])
+		last = pos
+
+		switch {
+		case closer:
+			if len(stack) > 1 && top.Name == name {
+				stack = stack[:len(stack)-1]
+			}
+		case void:
+			top.appendBlock(&Block{Name: name, Attrs: attrs})
+		default:
+			block := &Block{Name: name, Attrs: attrs}
+			top.appendBlock(block)
+			stack = append(stack, block)
+		}
+	}
+
+	stack[len(stack)-1].appendHTML(content[last:])
+
+	// Wrap top-level HTML between blocks as freeform blocks
+	var blocks []*Block
+	for _, part := range root.parts {
+		if part.block != nil {
+			blocks = append(blocks, part.block)
+		} else if strings.TrimSpace(part.html) != "" {
+			blocks = append(blocks, &Block{parts: []blockPart{{html: part.html}}})
+		}
+	}
+
+	return blocks
+}
+
+// parseDelimiter parses the body of an HTML comment as a block delimiter
+func parseDelimiter(body string) (name string, attrs map[string]interface{}, closer, void, ok bool) {
+	body = strings.TrimSpace(body)
+
+	if strings.HasPrefix(body, "/") {
+		closer = true
+		body = strings.TrimSpace(body[1:])
+	}
+
+	if !strings.HasPrefix(body, "wp:") {
+		return "", nil, false, false, false
+	}
+	body = body[3:]
+
+	name = blockNameRe.FindString(body)
+	if name == "" {
+		return "", nil, false, false, false
+	}
+	body = strings.TrimSpace(body[len(name):])
+	if !strings.Contains(name, "/") {
+		name = "core/" + name
+	}
+
+	if strings.HasSuffix(body, "/") {
+		void = true
+		body = strings.TrimSpace(strings.TrimSuffix(body, "/"))
+	}
+
+	attrs = make(map[string]interface{})
+	if body != "" {
+		if err := json.Unmarshal([]byte(body), &attrs); err != nil {
+			return "", nil, false, false, false
+		}
+	}
+
+	return name, attrs, closer, void, true
+}
+
+func (b *Block) appendHTML(html string) {
+	if html != "" {
+		b.parts = append(b.parts, blockPart{html: html})
+	}
+}
+
+func (b *Block) appendBlock(block *Block) {
+	b.parts = append(b.parts, blockPart{block: block})
+	b.InnerBlocks = append(b.InnerBlocks, block)
+}
+
+// IsFreeform reports whether the block is HTML outside of any delimiter
+func (b *Block) IsFreeform() bool {
+	return b.Name == ""
+}
+
+// InnerHTML returns the block's own HTML without its inner blocks
+func (b *Block) InnerHTML() string {
+	var sb strings.Builder
+	for _, part := range b.parts {
+		sb.WriteString(part.html)
+	}
+	return sb.String()
+}
+
+// HTML returns the block's HTML with all inner blocks rendered in place
+func (b *Block) HTML() string {
+	var sb strings.Builder
+	for _, part := range b.parts {
+		if part.block != nil {
+			sb.WriteString(part.block.HTML())
+		} else {
+			sb.WriteString(part.html)
+		}
+	}
+	return sb.String()
+}
+
+// AttrString returns a string attribute or the empty string
+func (b *Block) AttrString(key string) string {
+	if s, ok := b.Attrs[key].(string); ok {
+		return s
+	}
+	return ""
+}
+
+// AttrBool returns a boolean attribute or false
+func (b *Block) AttrBool(key string) bool {
+	v, _ := b.Attrs[key].(bool)
+	return v
+}
+
+// AttrInt returns a numeric attribute or def if it is missing
+func (b *Block) AttrInt(key string, def int) int {
+	if f, ok := b.Attrs[key].(float64); ok {
+		return int(f)
+	}
+	return def
+}
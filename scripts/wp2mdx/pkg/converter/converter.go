@@ -3,51 +3,120 @@ package converter
 import (
 	"fmt"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 
 	md "github.com/JohannesKaufmann/html-to-markdown"
+	"github.com/JohannesKaufmann/html-to-markdown/plugin"
 	"github.com/PuerkitoBio/goquery"
+
+	"github.com/aequinox/gesundes-leben/wp2mdx/pkg/models"
+	"github.com/aequinox/gesundes-leben/wp2mdx/pkg/parser"
 )
 
 // Converter handles HTML to Markdown conversion
 type Converter struct {
-	converter *md.Converter
+	converter   *md.Converter
+	attachments *parser.AttachmentIndex
 }
 
-// New creates a new HTML to Markdown converter
-func New() *Converter {
+// New creates a new HTML to Markdown converter. The attachment index
+// resolves images referenced by ID, e.g. in [gallery], and may be nil.
+func New(attachments *parser.AttachmentIndex) *Converter {
 	converter := md.NewConverter("", true, nil)
+	converter.Use(plugin.Table())
 
 	// Add custom rules
 	addCustomRules(converter)
 
 	return &Converter{
-		converter: converter,
+		converter:   converter,
+		attachments: attachments,
 	}
 }
 
+// Result is the outcome of converting a post's content
+type Result struct {
+	Markdown string
+	Warnings []string
+}
+
 // Convert converts HTML content to Markdown
 func (c *Converter) Convert(html string) (string, error) {
+	result, err := c.ConvertContent(html)
+	if err != nil {
+		return "", err
+	}
+	return result.Markdown, nil
+}
+
+// ConvertContent converts post content to Markdown. Gutenberg content is
+// parsed into blocks and each block type is rendered by its own renderer;
+// classic content is converted as a whole.
+func (c *Converter) ConvertContent(html string) (*Result, error) {
+	ctx := &renderContext{}
+	blocks := ParseBlocks(html)
+
+	var markdown string
+	var err error
+	if hasBlocks(blocks) {
+		markdown, err = c.renderBlocks(blocks, ctx)
+	} else {
+		markdown, err = c.convertHTML(html, ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Post-process the markdown and make it safe for MDX
+	markdown = EscapeMDX(c.postProcess(markdown))
+
+	return &Result{Markdown: markdown, Warnings: ctx.warnings}, nil
+}
+
+// convertHTML converts an HTML fragment with the generic rules after
+// translating its shortcodes
+func (c *Converter) convertHTML(html string, ctx *renderContext) (string, error) {
+	html, replacements, err := c.expandShortcodes(html, ctx)
+	if err != nil {
+		return "", err
+	}
+
 	markdown, err := c.converter.ConvertString(html)
 	if err != nil {
 		return "", fmt.Errorf("failed to convert HTML to Markdown: %w", err)
 	}
 
-	// Post-process the markdown
-	markdown = c.postProcess(markdown)
+	for i, mdx := range replacements {
+		markdown = strings.Replace(markdown, shortcodePlaceholder(i), mdx, 1)
+	}
 
 	return markdown, nil
 }
 
+// hasBlocks reports whether any block came from a block delimiter
+func hasBlocks(blocks []*Block) bool {
+	for _, b := range blocks {
+		if !b.IsFreeform() {
+			return true
+		}
+	}
+	return false
+}
+
 // addCustomRules adds custom conversion rules
 func addCustomRules(converter *md.Converter) {
 	// Rule for WordPress figures
 	converter.AddRules(md.Rule{
 		Filter: []string{"figure"},
 		Replacement: func(content string, selec *goquery.Selection, options *md.Options) *string {
-			// Extract image info
-			img := selec.Find("img")
-			src, _ := img.Attr("src")
+			// Extract image info; other figures (tables, embeds) keep their content
+			img := selec.Find("img").First()
+			src, ok := img.Attr("src")
+			if !ok {
+				return &content
+			}
 			alt, _ := img.Attr("alt")
 
 			// Check for alignment class
@@ -59,14 +128,11 @@ func addCustomRules(converter *md.Converter) {
 			}
 
 			// Get caption if exists
-			caption := selec.Find("figcaption").Text()
+			caption := strings.TrimSpace(selec.Find("figcaption").Text())
 
 			// Create markdown image reference
 			// This will be replaced with Astro Image component later
-			result := fmt.Sprintf("![%s](%s){position=%s}", alt, src, position)
-			if caption != "" {
-				result += fmt.Sprintf("\n*%s*", caption)
-			}
+			result := "\n\n" + imageMarkdown(alt, src, caption, position) + "\n\n"
 
 			return &result
 		},
@@ -205,10 +271,15 @@ func ExtractImages(html string) []ImageInfo {
 			}
 		}
 
+		srcset, _ := s.Attr("srcset")
+		class, _ := s.Attr("class")
+
 		images = append(images, ImageInfo{
-			URL:      src,
-			Alt:      alt,
-			Position: position,
+			URL:          src,
+			Alt:          alt,
+			Position:     position,
+			Srcset:       parseSrcset(srcset),
+			AttachmentID: attachmentIDFromClass(class),
 		})
 
 		seen[src] = true
@@ -222,38 +293,161 @@ type ImageInfo struct {
 	URL      string
 	Alt      string
 	Position string
+	// Srcset lists the srcset candidate URLs, largest first
+	Srcset []string
+	// AttachmentID is taken from WordPress's wp-image-<ID> class, or 0
+	AttachmentID int
+}
+
+// srcsetDescriptorRe matches the width or density descriptor of a candidate
+var srcsetDescriptorRe = regexp.MustCompile(`^(\d+(?:\.\d+)?)[wx]$`)
+
+// parseSrcset parses a srcset attribute and orders its URLs by descriptor,
+// largest first
+func parseSrcset(srcset string) []string {
+	type candidate struct {
+		url  string
+		size float64
+	}
+
+	var candidates []candidate
+	for _, part := range strings.Split(srcset, ",") {
+		fields := strings.Fields(part)
+		if len(fields) == 0 {
+			continue
+		}
+		c := candidate{url: fields[0], size: 1}
+		if len(fields) > 1 {
+			if m := srcsetDescriptorRe.FindStringSubmatch(fields[1]); m != nil {
+				c.size, _ = strconv.ParseFloat(m[1], 64)
+			}
+		}
+		candidates = append(candidates, c)
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].size > candidates[j].size
+	})
+
+	urls := make([]string, len(candidates))
+	for i, c := range candidates {
+		urls[i] = c.url
+	}
+	return urls
+}
+
+// wpImageClassRe matches the attachment class WordPress adds to images
+var wpImageClassRe = regexp.MustCompile(`(?:^|\s)wp-image-(\d+)(?:\s|$)`)
+
+// attachmentIDFromClass returns the attachment ID from a wp-image-<ID> class
+func attachmentIDFromClass(class string) int {
+	m := wpImageClassRe.FindStringSubmatch(class)
+	if m == nil {
+		return 0
+	}
+	id, _ := strconv.Atoi(m[1])
+	return id
 }
 
 // ConvertToImageComponent converts image markdown to Astro Image component
 func ConvertToImageComponent(markdown string, images map[string]string) string {
+	return ConvertToImageComponentFunc(markdown, func(src string) (*models.ImageRef, bool) {
+		varName, ok := images[src]
+		return &models.ImageRef{Variable: varName}, ok
+	})
+}
+
+// ConvertToImageComponentFunc converts image markdown to Astro Image
+// components, using resolve to find the downloaded image for each source URL.
+// Alt text and captions missing in the content are taken from the image.
+// Images that cannot be resolved stay Markdown without their position suffix.
+func ConvertToImageComponentFunc(markdown string, resolve func(src string) (*models.ImageRef, bool)) string {
 	// Replace markdown images with Astro Image components
-	re := regexp.MustCompile(`!\[(.*?)\]\((.*?)\)(?:\{position=(.*?)\})?`)
+	re := regexp.MustCompile(`!\[(.*?)\]\((\S*?)(?: "([^"]*)")?\)(?:\{position=(.*?)\})?`)
 
 	markdown = re.ReplaceAllStringFunc(markdown, func(match string) string {
 		matches := re.FindStringSubmatch(match)
-		if len(matches) < 3 {
+		if len(matches) < 5 {
 			return match
 		}
 
-		alt := matches[1]
+		alt := jsxAttrValue(matches[1])
 		src := matches[2]
+		caption := jsxAttrValue(matches[3])
 		position := "center"
-		if len(matches) > 3 && matches[3] != "" {
-			position = matches[3]
+		if matches[4] != "" {
+			position = matches[4]
 		}
 
-		// Get the variable name for this image
-		varName, ok := images[src]
+		// Get the downloaded image for this source
+		img, ok := resolve(src)
 		if !ok {
 			// If we don't have a mapping, keep the original
 			return match
 		}
+		if alt == "" {
+			alt = strings.ReplaceAll(img.Alt, `"`, "&quot;")
+		}
+		if caption == "" {
+			caption = strings.ReplaceAll(img.Caption, `"`, "&quot;")
+		}
 
 		// Generate Astro Image component
-		return fmt.Sprintf("\n<Image\n  src={%s}\n  alt=\"%s\"\n  position=\"%s\"\n/>\n", varName, alt, position)
+		title := ""
+		if caption != "" {
+			title = fmt.Sprintf("\n  title=\"%s\"", caption)
+		}
+		return fmt.Sprintf("\n<Image\n  src={%s}\n  alt=\"%s\"%s\n  position=\"%s\"\n/>\n", img.Variable, alt, title, position)
 	})
 
-	return markdown
+	return stripImagePositions(markdown)
+}
+
+// stripImagePositions removes the position suffix from images left as
+// Markdown, which MDX would otherwise parse as an expression
+func stripImagePositions(markdown string) string {
+	var sb strings.Builder
+	for _, seg := range scanMarkdown(markdown) {
+		if seg.kind == segImage {
+			seg.raw = seg.raw[:strings.LastIndexByte(seg.raw, ')')+1]
+		}
+		sb.WriteString(seg.raw)
+	}
+	return sb.String()
+}
+
+// jsxAttrValue turns escaped Markdown text into a JSX string attribute
+// value, where braces and "<" are literal and only quotes need escaping
+func jsxAttrValue(text string) string {
+	text = strings.NewReplacer(`\{`, "{", `\}`, "}", `\<`, "<").Replace(text)
+	return strings.ReplaceAll(text, `"`, "&quot;")
+}
+
+// componentImports lists the MDX components the converter may emit and the
+// modules they are imported from
+var componentImports = []struct {
+	name string
+	path string
+}{
+	{"Image", "@/components/elements/Image.astro"},
+	{"Blockquote", "@/components/elements/Blockquote.astro"},
+	{"Accordion", "@/components/sections/Accordion.astro"},
+	{"InternalLink", "@/components/elements/InternalLink.astro"},
+	{"GlossaryTooltip", "@/components/elements/GlossaryTooltip.astro"},
+}
+
+// ComponentImports returns import statements for the components used in the
+// converted markdown
+func ComponentImports(markdown string) []string {
+	var imports []string
+	for _, component := range componentImports {
+		if strings.Contains(markdown, "<"+component.name+"\n") ||
+			strings.Contains(markdown, "<"+component.name+">") ||
+			strings.Contains(markdown, "<"+component.name+" ") {
+			imports = append(imports, fmt.Sprintf("import %s from \"%s\";", component.name, component.path))
+		}
+	}
+	return imports
 }
 
 // ImageURLToVariable converts an image filename to a camelCase variable name
@@ -0,0 +1,46 @@
+package converter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aequinox/gesundes-leben/wp2mdx/pkg/models"
+)
+
+func TestConvertToImageComponentFunc(t *testing.T) {
+	markdown := "![Darm](https://example.com/darm.jpg \"Der Darm\"){position=right}\n\n" +
+		"![Hirn](https://example.com/hirn.jpg){position=left}"
+	resolve := func(src string) (*models.ImageRef, bool) {
+		return &models.ImageRef{Variable: "darmImage"}, src == "https://example.com/darm.jpg"
+	}
+
+	got := ConvertToImageComponentFunc(markdown, resolve)
+	want := "\n<Image\n  src={darmImage}\n  alt=\"Darm\"\n  title=\"Der Darm\"\n  position=\"right\"\n/>\n\n\n" +
+		"![Hirn](https://example.com/hirn.jpg)"
+	if got != want {
+		t.Errorf("ConvertToImageComponentFunc() = %q, want %q", got, want)
+	}
+}
+
+func TestConvertImagesWithoutResolution(t *testing.T) {
+	html := `<figure class="wp-block-image alignleft"><img src="https://example.com/darm.jpg" alt="Darm"><figcaption>Der {Darm}</figcaption></figure>` +
+		`<p>Text mit {position=left} im Satz</p>`
+
+	result, err := New(nil).ConvertContent(html)
+	if err != nil {
+		t.Fatalf("ConvertContent() error = %v", err)
+	}
+	got := ConvertToImageComponentFunc(result.Markdown, func(string) (*models.ImageRef, bool) {
+		return nil, false
+	})
+
+	if !strings.Contains(got, `![Darm](https://example.com/darm.jpg "Der \{Darm\}")`) {
+		t.Errorf("image should stay Markdown, got:\n%s", got)
+	}
+	if strings.Contains(got, "){position=") {
+		t.Errorf("position suffix should be removed, got:\n%s", got)
+	}
+	if !strings.Contains(got, `Text mit \{position=left\} im Satz`) {
+		t.Errorf("position text should be escaped, got:\n%s", got)
+	}
+}
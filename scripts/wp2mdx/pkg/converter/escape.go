@@ -0,0 +1,54 @@
+package converter
+
+import (
+	"fmt"
+	"strings"
+)
+
+// EscapeMDX escapes characters that MDX would parse as JSX or expressions:
+// "{", "}" and "<" in text, alt text and link titles. Lines starting with
+// "import " or "export " would be parsed as ESM, so their first letter is
+// written as a character reference. Code, link destinations and the
+// component tags emitted by the converter are left untouched.
+func EscapeMDX(markdown string) string {
+	var sb strings.Builder
+	sb.Grow(len(markdown))
+
+	lineStart := true
+	for _, seg := range scanMarkdown(markdown) {
+		switch seg.kind {
+		case segText, segURL:
+			for i := 0; i < len(seg.raw); i++ {
+				atLineStart := (i == 0 && lineStart) || (i > 0 && seg.raw[i-1] == '\n')
+				switch c := seg.raw[i]; {
+				case atLineStart && (strings.HasPrefix(seg.raw[i:], "import ") || strings.HasPrefix(seg.raw[i:], "export ")):
+					fmt.Fprintf(&sb, "&#%d;", c)
+				case c == '\\' && i+1 < len(seg.raw):
+					// Already escaped, e.g. "\_" in a URL
+					sb.WriteString(seg.raw[i : i+2])
+					i++
+				case c == '{' || c == '}' || c == '<':
+					sb.WriteByte('\\')
+					sb.WriteByte(c)
+				default:
+					sb.WriteByte(c)
+				}
+			}
+
+		case segLink, segImage:
+			// Only the destination is kept as is; an image keeps its
+			// position suffix too
+			if seg.kind == segImage {
+				sb.WriteByte('!')
+			}
+			sb.WriteString("[" + EscapeMDX(seg.link.text) + "](" + seg.link.href + EscapeMDX(seg.link.title) + ")")
+			sb.WriteString(seg.raw[strings.LastIndexByte(seg.raw, ')')+1:])
+
+		default:
+			sb.WriteString(seg.raw)
+		}
+		lineStart = strings.HasSuffix(seg.raw, "\n")
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,60 @@
+package converter
+
+import (
+	"slices"
+	"strings"
+)
+
+// inlineComponents are components whose content is already a link, so
+// terms inside them are not linked again
+var inlineComponents = []string{"InternalLink", "GlossaryTooltip"}
+
+// LinkTermsFunc passes each run of prose in markdown to link and replaces
+// it with the result. Headings, code, links, images, URLs, component tags
+// and the content of link components are not prose. Emphasis markers and escapes end a run, so link never wraps text
+// across them.
+func LinkTermsFunc(markdown string, link func(text string) string) string {
+	var sb strings.Builder
+	sb.Grow(len(markdown))
+
+	var prose strings.Builder
+	flush := func() {
+		if prose.Len() > 0 {
+			sb.WriteString(link(prose.String()))
+			prose.Reset()
+		}
+	}
+	// copyRaw writes s unchanged, ending the current run of prose
+	copyRaw := func(s string) {
+		flush()
+		sb.WriteString(s)
+	}
+
+	// Content of a link component up to its closing tag is not prose
+	inside := ""
+
+	for _, seg := range scanMarkdown(markdown) {
+		switch {
+		case inside != "":
+			copyRaw(seg.raw)
+			if seg.kind == segTag && seg.name == inside && strings.HasPrefix(seg.raw, "</") {
+				inside = ""
+			}
+
+		case seg.kind == segText && !seg.heading:
+			prose.WriteString(seg.raw)
+
+		case seg.kind == segTag:
+			copyRaw(seg.raw)
+			if slices.Contains(inlineComponents, seg.name) && !strings.HasPrefix(seg.raw, "</") && !strings.HasSuffix(seg.raw, "/>") {
+				inside = seg.name
+			}
+
+		default:
+			copyRaw(seg.raw)
+		}
+	}
+	flush()
+
+	return sb.String()
+}
@@ -0,0 +1,41 @@
+package converter
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RewriteLinksFunc rewrites the destinations of Markdown links using
+// resolve, which returns the new href and whether the link should change.
+// With component set, rewritten links become InternalLink components.
+// Images and links in code are left untouched.
+func RewriteLinksFunc(markdown string, resolve func(href string) (string, bool), component bool) string {
+	var sb strings.Builder
+	sb.Grow(len(markdown))
+
+	for _, seg := range scanMarkdown(markdown) {
+		if seg.kind != segLink {
+			sb.WriteString(seg.raw)
+			continue
+		}
+		link := seg.link
+
+		// Links in the link text, e.g. around images, are rewritten too
+		text := RewriteLinksFunc(link.text, resolve, component)
+		href, ok := resolve(link.href)
+		switch {
+		case !ok:
+			sb.WriteString("[" + text + "](" + link.href + link.title + ")")
+		case component:
+			title := ""
+			if t := strings.TrimSpace(link.title); t != "" {
+				title = fmt.Sprintf(" title=\"%s\"", jsxAttrValue(strings.ReplaceAll(t[1:len(t)-1], `\"`, `"`)))
+			}
+			fmt.Fprintf(&sb, "<InternalLink href=\"%s\"%s>%s</InternalLink>", href, title, text)
+		default:
+			sb.WriteString("[" + text + "](" + href + link.title + ")")
+		}
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,70 @@
+package converter
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+// ReadingTime is the reading time estimate of converted Markdown
+type ReadingTime struct {
+	Words   int
+	Images  int
+	Minutes int
+}
+
+// EstimateReadingTime estimates the reading time of the final MDX body.
+// Code blocks and spans, component tags and link destinations are not
+// counted as words; images (Image components and remaining Markdown images)
+// cost imageSeconds each. The result is rounded up to whole minutes and is 0
+// only for empty content.
+func EstimateReadingTime(markdown string, wordsPerMinute, imageSeconds int) ReadingTime {
+	var prose strings.Builder
+	images := 0
+
+	var read func(markdown string)
+	read = func(markdown string) {
+		for _, seg := range scanMarkdown(markdown) {
+			switch seg.kind {
+			case segText, segMarker, segURL:
+				prose.WriteString(seg.raw)
+			case segEscape:
+				prose.WriteString(seg.raw[1:])
+			case segLink:
+				// The link text is read, the destination is not
+				read(seg.link.text)
+				prose.WriteByte(' ')
+			case segImage:
+				// The alt text of an image is not read
+				images++
+				prose.WriteByte(' ')
+			case segTag:
+				if seg.name == "Image" && !strings.HasPrefix(seg.raw, "</") {
+					images++
+				}
+				prose.WriteByte(' ')
+			case segCode:
+				prose.WriteByte(' ')
+			}
+		}
+	}
+	read(markdown)
+
+	rt := ReadingTime{Images: images}
+	for _, field := range strings.Fields(prose.String()) {
+		if strings.IndexFunc(field, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }) != -1 {
+			rt.Words++
+		}
+	}
+
+	if wordsPerMinute < 1 {
+		wordsPerMinute = 1
+	}
+	seconds := float64(rt.Words)/float64(wordsPerMinute)*60 + float64(rt.Images*imageSeconds)
+	rt.Minutes = int(math.Ceil(seconds / 60))
+	if rt.Minutes == 0 && rt.Words+rt.Images > 0 {
+		rt.Minutes = 1
+	}
+
+	return rt
+}
@@ -0,0 +1,416 @@
+package converter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// blockRenderer converts a single block to MDX
+type blockRenderer func(c *Converter, b *Block, ctx *renderContext) (string, error)
+
+// renderContext carries per-post state through a conversion
+type renderContext struct {
+	warnings []string
+	reported map[string]bool
+}
+
+func (ctx *renderContext) warnf(format string, args ...interface{}) {
+	ctx.warnings = append(ctx.warnings, fmt.Sprintf(format, args...))
+}
+
+// warnOnce records a warning only the first time it occurs in a post
+func (ctx *renderContext) warnOnce(format string, args ...interface{}) {
+	message := fmt.Sprintf(format, args...)
+	if ctx.reported[message] {
+		return
+	}
+	if ctx.reported == nil {
+		ctx.reported = make(map[string]bool)
+	}
+	ctx.reported[message] = true
+	ctx.warnings = append(ctx.warnings, message)
+}
+
+// blockRenderers maps block names to renderers. Blocks without an entry are
+// converted from their HTML.
+var blockRenderers map[string]blockRenderer
+
+func init() {
+	blockRenderers = map[string]blockRenderer{
+		// Media
+		"core/image":    renderImage,
+		"kadence/image": renderImage,
+		"core/gallery":  renderGallery,
+
+		// Text
+		"core/quote":       renderQuote,
+		"core/pullquote":   renderQuote,
+		"core/table":       renderTable,
+		"core/list":        renderList,
+		"kadence/iconlist": renderList,
+
+		// Layout containers are flattened into their content
+		"core/columns":      renderChildren,
+		"core/column":       renderChildren,
+		"core/group":        renderChildren,
+		"kadence/rowlayout": renderChildren,
+		"kadence/column":    renderChildren,
+
+		// Collapsible content
+		"core/details":      renderDetails,
+		"kadence/accordion": renderAccordion,
+
+		// Blocks without content in the export
+		"core/spacer":    renderNothing,
+		"kadence/spacer": renderNothing,
+		"core/footnotes": renderNothing,
+		"core/more":      renderNothing,
+		"core/nextpage":  renderNothing,
+		"core/block":     renderReusable,
+	}
+}
+
+// renderBlocks renders a sequence of blocks separated by blank lines
+func (c *Converter) renderBlocks(blocks []*Block, ctx *renderContext) (string, error) {
+	var parts []string
+	for _, b := range blocks {
+		md, err := c.renderBlock(b, ctx)
+		if err != nil {
+			return "", err
+		}
+		if strings.TrimSpace(md) != "" {
+			parts = append(parts, md)
+		}
+	}
+	return strings.Join(parts, "\n\n"), nil
+}
+
+// renderBlock dispatches a block to its renderer
+func (c *Converter) renderBlock(b *Block, ctx *renderContext) (string, error) {
+	if renderer, ok := blockRenderers[b.Name]; ok {
+		return renderer(c, b, ctx)
+	}
+	return renderHTML(c, b, ctx)
+}
+
+// renderHTML converts the block's full HTML with the generic rules
+func renderHTML(c *Converter, b *Block, ctx *renderContext) (string, error) {
+	return c.convertHTML(b.HTML(), ctx)
+}
+
+// renderChildren renders only the inner blocks, dropping wrapper markup
+func renderChildren(c *Converter, b *Block, ctx *renderContext) (string, error) {
+	if len(b.InnerBlocks) == 0 {
+		return renderHTML(c, b, ctx)
+	}
+	return c.renderBlocks(b.InnerBlocks, ctx)
+}
+
+// renderNothing drops blocks that carry no content
+func renderNothing(c *Converter, b *Block, ctx *renderContext) (string, error) {
+	return "", nil
+}
+
+// renderReusable drops references to reusable blocks, whose content is not
+// part of the post
+func renderReusable(c *Converter, b *Block, ctx *renderContext) (string, error) {
+	ctx.warnf("reusable block %d not included", b.AttrInt("ref", 0))
+	return "", nil
+}
+
+// renderImage renders an image block as a Markdown image that is later
+// turned into an <Image> component. Alignment comes from the block attrs.
+func renderImage(c *Converter, b *Block, ctx *renderContext) (string, error) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(b.InnerHTML()))
+	if err != nil {
+		return "", err
+	}
+
+	img := doc.Find("img").First()
+	src, ok := img.Attr("src")
+	if !ok {
+		return "", nil
+	}
+	alt, _ := img.Attr("alt")
+	caption := strings.TrimSpace(doc.Find("figcaption").Text())
+
+	position := "center"
+	switch b.AttrString("align") {
+	case "left":
+		position = "left"
+	case "right":
+		position = "right"
+	}
+
+	return imageMarkdown(alt, src, caption, position), nil
+}
+
+// renderGallery renders each image of a gallery in order
+func renderGallery(c *Converter, b *Block, ctx *renderContext) (string, error) {
+	if len(b.InnerBlocks) > 0 {
+		return c.renderBlocks(b.InnerBlocks, ctx)
+	}
+
+	// Legacy galleries keep all images in the block's own HTML
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(b.InnerHTML()))
+	if err != nil {
+		return "", err
+	}
+
+	var images []string
+	doc.Find("img").Each(func(i int, s *goquery.Selection) {
+		src, ok := s.Attr("src")
+		if !ok {
+			return
+		}
+		alt, _ := s.Attr("alt")
+		caption := strings.TrimSpace(s.Closest("figure").Find("figcaption").Text())
+		images = append(images, imageMarkdown(alt, src, caption, "center"))
+	})
+
+	return strings.Join(images, "\n\n"), nil
+}
+
+// renderQuote renders quotes as <Blockquote>. Blockquote.astro only sets
+// its cite prop on the HTML attribute, which takes a source URL and is not
+// shown, so the attribution stays in the content as a "— Name" line.
+func renderQuote(c *Converter, b *Block, ctx *renderContext) (string, error) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(b.InnerHTML()))
+	if err != nil {
+		return "", err
+	}
+
+	source, _ := doc.Find("blockquote").Attr("cite")
+	source = strings.TrimSpace(source)
+
+	attribution := ""
+	if cite := doc.Find("cite"); strings.TrimSpace(cite.Text()) != "" {
+		inner, _ := cite.Html()
+		if attribution, err = c.convertHTML(inner, ctx); err != nil {
+			return "", err
+		}
+	}
+	doc.Find("cite").Remove()
+
+	var content string
+	if len(b.InnerBlocks) > 0 {
+		content, err = c.renderBlocks(b.InnerBlocks, ctx)
+	} else {
+		inner, _ := doc.Find("blockquote").Html()
+		content, err = c.convertHTML(inner, ctx)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	content = strings.TrimSpace(content)
+	if attribution = strings.TrimSpace(attribution); attribution != "" {
+		content += "\n\n— " + attribution
+	}
+
+	open := "<Blockquote>"
+	if source != "" {
+		open = fmt.Sprintf("<Blockquote cite=\"%s\">", jsxAttrValue(source))
+	}
+
+	return fmt.Sprintf("%s\n%s\n</Blockquote>", open, content), nil
+}
+
+// renderTable converts the table and appends its caption
+func renderTable(c *Converter, b *Block, ctx *renderContext) (string, error) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(b.InnerHTML()))
+	if err != nil {
+		return "", err
+	}
+
+	table, err := goquery.OuterHtml(doc.Find("table").First())
+	if err != nil || table == "" {
+		return renderHTML(c, b, ctx)
+	}
+
+	md, err := c.convertHTML(table, ctx)
+	if err != nil {
+		return "", err
+	}
+
+	if caption := strings.TrimSpace(doc.Find("figcaption").Text()); caption != "" {
+		md += "\n\n*" + caption + "*"
+	}
+
+	return md, nil
+}
+
+// renderList renders list blocks from their list-item children, honoring
+// the ordered, start and reversed attributes
+func renderList(c *Converter, b *Block, ctx *renderContext) (string, error) {
+	return c.renderListItems(b, ctx, "")
+}
+
+func (c *Converter) renderListItems(b *Block, ctx *renderContext, indent string) (string, error) {
+	if len(b.InnerBlocks) == 0 {
+		// Lists saved before list items became blocks
+		return renderHTML(c, b, ctx)
+	}
+
+	ordered := b.AttrBool("ordered")
+	number := b.AttrInt("start", 1)
+	step := 1
+	if b.AttrBool("reversed") {
+		if _, ok := b.Attrs["start"]; !ok {
+			number = len(b.InnerBlocks)
+		}
+		step = -1
+	}
+
+	var lines []string
+	for _, item := range b.InnerBlocks {
+		doc, err := goquery.NewDocumentFromReader(strings.NewReader(item.InnerHTML()))
+		if err != nil {
+			return "", err
+		}
+
+		li := doc.Find("li").First()
+		li.Find("ul, ol").Remove()
+		inner, _ := li.Html()
+		if li.Length() == 0 {
+			inner = item.InnerHTML()
+		}
+
+		text, err := c.convertHTML(inner, ctx)
+		if err != nil {
+			return "", err
+		}
+
+		marker := "- "
+		if ordered {
+			marker = strconv.Itoa(number) + ". "
+			number += step
+		}
+		lines = append(lines, indent+marker+strings.ReplaceAll(strings.TrimSpace(text), "\n", "\n"+indent+"  "))
+
+		// Nested lists are inner blocks of the list item
+		for _, nested := range item.InnerBlocks {
+			md, err := c.renderListItems(nested, ctx, indent+"  ")
+			if err != nil {
+				return "", err
+			}
+			if md != "" {
+				lines = append(lines, md)
+			}
+		}
+	}
+
+	return strings.Join(lines, "\n"), nil
+}
+
+// accordionItem is one collapsible section of an <Accordion>
+type accordionItem struct {
+	title   string
+	content string
+	open    bool
+}
+
+// renderDetails renders a details block as a single-item <Accordion>
+func renderDetails(c *Converter, b *Block, ctx *renderContext) (string, error) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(b.InnerHTML()))
+	if err != nil {
+		return "", err
+	}
+
+	content, err := renderChildren(c, b, ctx)
+	if err != nil {
+		return "", err
+	}
+	if len(b.InnerBlocks) == 0 {
+		doc.Find("summary").Remove()
+		inner, _ := doc.Find("details").Html()
+		if content, err = c.convertHTML(inner, ctx); err != nil {
+			return "", err
+		}
+	}
+
+	return accordionMarkup([]accordionItem{{
+		title:   strings.TrimSpace(doc.Find("summary").Text()),
+		content: content,
+		open:    b.AttrBool("showContent"),
+	}}), nil
+}
+
+// renderAccordion renders a Kadence accordion and its panes as <Accordion>
+func renderAccordion(c *Converter, b *Block, ctx *renderContext) (string, error) {
+	openPane := -1
+	if !b.AttrBool("startCollapsed") {
+		openPane = b.AttrInt("openPane", 0)
+	}
+
+	var items []accordionItem
+	for i, pane := range b.InnerBlocks {
+		doc, err := goquery.NewDocumentFromReader(strings.NewReader(pane.InnerHTML()))
+		if err != nil {
+			return "", err
+		}
+
+		content, err := c.renderBlocks(pane.InnerBlocks, ctx)
+		if err != nil {
+			return "", err
+		}
+
+		items = append(items, accordionItem{
+			title:   strings.TrimSpace(doc.Find(".kt-blocks-accordion-title").Text()),
+			content: content,
+			open:    i == openPane,
+		})
+	}
+
+	if len(items) == 0 {
+		return "", nil
+	}
+
+	// Accordion.astro renders a limited number of content slots, so longer
+	// accordions are split into consecutive ones
+	if len(items) > accordionSlots {
+		ctx.warnf("accordion with %d panes split into %d accordions of at most %d", len(items), (len(items)+accordionSlots-1)/accordionSlots, accordionSlots)
+	}
+	var parts []string
+	for start := 0; start < len(items); start += accordionSlots {
+		parts = append(parts, accordionMarkup(items[start:min(start+accordionSlots, len(items))]))
+	}
+
+	return strings.Join(parts, "\n\n"), nil
+}
+
+// accordionSlots is the number of content slots Accordion.astro renders,
+// content-0 to content-9
+const accordionSlots = 10
+
+// accordionMarkup builds an <Accordion> whose item contents are passed as
+// named slots, so they may contain Markdown
+func accordionMarkup(items []accordionItem) string {
+	var sb strings.Builder
+
+	sb.WriteString("<Accordion\n  items={[\n")
+	for i, item := range items {
+		fmt.Fprintf(&sb, "    { title: %q, slotId: %d, open: %t },\n", item.title, i, item.open)
+	}
+	sb.WriteString("  ]}\n>\n")
+
+	for i, item := range items {
+		fmt.Fprintf(&sb, "<div slot=\"content-%d\">\n\n%s\n\n</div>\n", i, strings.TrimSpace(item.content))
+	}
+	sb.WriteString("</Accordion>")
+
+	return sb.String()
+}
+
+// imageMarkdown builds the intermediate Markdown image syntax understood by
+// ConvertToImageComponent; the caption is carried as the image title
+func imageMarkdown(alt, src, caption, position string) string {
+	title := ""
+	if caption != "" {
+		title = ` "` + strings.ReplaceAll(caption, `"`, "&quot;") + `"`
+	}
+	return fmt.Sprintf("![%s](%s%s){position=%s}", alt, src, title, position)
+}
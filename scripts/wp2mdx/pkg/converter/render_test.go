@@ -0,0 +1,58 @@
+package converter
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestRenderQuote(t *testing.T) {
+	html := `<!-- wp:quote -->
+<blockquote class="wp-block-quote"><p>Der Darm ist das "zweite Gehirn".</p><cite>Prof. <em>Anna</em> Weber</cite></blockquote>
+<!-- /wp:quote -->`
+
+	result, err := New(nil).ConvertContent(html)
+	if err != nil {
+		t.Fatalf("ConvertContent() error = %v", err)
+	}
+
+	got := result.Markdown
+	if !strings.HasPrefix(got, "<Blockquote>\n") {
+		t.Errorf("quote should open without a cite prop, got:\n%s", got)
+	}
+	if !strings.Contains(got, "— Prof. _Anna_ Weber\n</Blockquote>") {
+		t.Errorf("attribution should stay visible at the end of the quote, got:\n%s", got)
+	}
+}
+
+func TestRenderAccordionSplitsPanes(t *testing.T) {
+	var sb strings.Builder
+	sb.WriteString(`<!-- wp:kadence/accordion {"startCollapsed":true} --><div class="wp-block-kadence-accordion">`)
+	for i := 0; i < 12; i++ {
+		fmt.Fprintf(&sb, `<!-- wp:kadence/pane {"id":%d} --><div class="wp-block-kadence-pane"><h3 class="kt-accordion-header-wrap"><span class="kt-blocks-accordion-title">Frage %d</span></h3><div class="kt-accordion-panel-inner">`, i+1, i)
+		fmt.Fprintf(&sb, `<!-- wp:paragraph --><p>Antwort %d</p><!-- /wp:paragraph -->`, i)
+		sb.WriteString(`</div></div><!-- /wp:kadence/pane -->`)
+	}
+	sb.WriteString(`</div><!-- /wp:kadence/accordion -->`)
+
+	result, err := New(nil).ConvertContent(sb.String())
+	if err != nil {
+		t.Fatalf("ConvertContent() error = %v", err)
+	}
+
+	got := result.Markdown
+	if n := strings.Count(got, "<Accordion\n"); n != 2 {
+		t.Errorf("got %d accordions, want 2:\n%s", n, got)
+	}
+	if strings.Contains(got, `slot="content-10"`) {
+		t.Errorf("no pane should use a slot beyond content-9:\n%s", got)
+	}
+	for i := 0; i < 12; i++ {
+		if !strings.Contains(got, fmt.Sprintf("Antwort %d", i)) {
+			t.Errorf("pane %d is missing:\n%s", i, got)
+		}
+	}
+	if len(result.Warnings) != 1 || !strings.Contains(result.Warnings[0], "split") {
+		t.Errorf("Warnings = %v, want one warning about the split", result.Warnings)
+	}
+}
@@ -0,0 +1,361 @@
+package converter
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// imagePositionRe matches the position suffix of intermediate image markdown
+var imagePositionRe = regexp.MustCompile(`^\{position=[a-z]+\}`)
+
+// jsxTagNameRe matches the name of an opening or closing JSX tag
+var jsxTagNameRe = regexp.MustCompile(`^</?([A-Za-z][A-Za-z0-9]*)`)
+
+// segmentKind is the kind of a segment of converted Markdown
+type segmentKind int
+
+const (
+	segText   segmentKind = iota // prose
+	segMarker                    // emphasis or strikethrough marker
+	segEscape                    // backslash escape
+	segCode                      // fenced code block line or code span
+	segLink                      // inline link
+	segImage                     // image, including its position suffix
+	segTag                       // component tag emitted by the converter
+	segURL                       // autolink or bare URL
+)
+
+// segment is a piece of converted Markdown
+type segment struct {
+	kind    segmentKind
+	raw     string       // the segment as it appears in the Markdown
+	heading bool         // prose on an ATX heading line
+	link    markdownLink // link or image
+	name    string       // tag name
+}
+
+// scanMarkdown splits converted Markdown into segments whose raw texts add
+// up to markdown again. The Markdown passes, starting with EscapeMDX, are
+// built on it so they agree on what is code, a link, an image, a tag or a
+// URL.
+func scanMarkdown(markdown string) []segment {
+	var segments []segment
+	textStart := 0
+	heading := false
+
+	// flush ends the pending prose at i
+	flush := func(i int) {
+		if textStart < i {
+			segments = append(segments, segment{kind: segText, raw: markdown[textStart:i], heading: heading})
+		}
+		textStart = i
+	}
+	// add appends seg with the n bytes at i and returns the next position
+	add := func(i, n int, seg segment) int {
+		flush(i)
+		seg.raw = markdown[i : i+n]
+		segments = append(segments, seg)
+		textStart = i + n
+		return i + n
+	}
+
+	lineStart := true
+	fence := ""
+
+	for i := 0; i < len(markdown); {
+		rest := markdown[i:]
+
+		if lineStart {
+			lineStart = false
+
+			line := rest
+			if end := strings.IndexByte(rest, '\n'); end != -1 {
+				line = rest[:end+1]
+			}
+			trimmed := strings.TrimLeft(line, " ")
+
+			// Fenced code blocks are whole lines
+			if fence != "" || strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+				marker := strings.TrimSpace(line)
+				switch {
+				case fence == "":
+					fence = marker[:3]
+				case strings.HasPrefix(marker, fence) && strings.Trim(marker, fence[:1]) == "":
+					fence = ""
+				}
+				i = add(i, len(line), segment{kind: segCode})
+				lineStart = true
+				continue
+			}
+
+			if h := isHeading(trimmed); h != heading {
+				flush(i)
+				heading = h
+			}
+		}
+
+		switch c := markdown[i]; {
+		case c == '\n':
+			i++
+			lineStart = true
+
+		case c == '\\' && i+1 < len(markdown):
+			_, size := utf8.DecodeRuneInString(rest[1:])
+			i = add(i, 1+size, segment{kind: segEscape})
+			lineStart = rest[1] == '\n'
+
+		case c == '*' || c == '_' || c == '~':
+			i = add(i, 1, segment{kind: segMarker})
+
+		case c == '`':
+			i = add(i, codeSpanLength(rest), segment{kind: segCode})
+
+		case c == '!' && strings.HasPrefix(rest, "!["):
+			link, n := parseMarkdownLink(rest[1:])
+			if n == 0 {
+				// Not an image; the bracket is not a link either
+				i += 2
+				continue
+			}
+			n++
+			n += len(imagePositionRe.FindString(rest[n:]))
+			i = add(i, n, segment{kind: segImage, link: link})
+
+		case c == '[':
+			link, n := parseMarkdownLink(rest)
+			if n == 0 {
+				i++
+				continue
+			}
+			i = add(i, n, segment{kind: segLink, link: link})
+
+		case c == '<':
+			if n := jsxTagLength(rest); n > 0 {
+				i = add(i, n, segment{kind: segTag, name: jsxTagNameRe.FindStringSubmatch(rest)[1]})
+			} else if n := autolinkLength(rest); n > 0 {
+				i = add(i, n, segment{kind: segURL})
+			} else {
+				i++
+			}
+
+		case isURLStart(markdown, i):
+			// A bare URL ends at whitespace or the next bit of syntax
+			n := strings.IndexAny(rest, " \t\n<[]`")
+			if n == -1 {
+				n = len(rest)
+			} else if rest[n] == '[' && rest[n-1] == '!' {
+				n--
+			}
+			i = add(i, n, segment{kind: segURL})
+
+		default:
+			i++
+		}
+	}
+	flush(len(markdown))
+
+	return segments
+}
+
+// isHeading reports whether a line is an ATX heading; "#hashtag" is not
+func isHeading(line string) bool {
+	marker := strings.TrimLeft(line, "#")
+	level := len(line) - len(marker)
+	return level >= 1 && level <= 6 && (marker == "" || marker[0] == ' ' || marker[0] == '\t' || marker[0] == '\n')
+}
+
+// isURLStart reports whether a bare URL starts at markdown[i]
+func isURLStart(markdown string, i int) bool {
+	rest := markdown[i:]
+	if !strings.HasPrefix(rest, "http://") && !strings.HasPrefix(rest, "https://") && !strings.HasPrefix(rest, "www.") {
+		return false
+	}
+	prev, _ := utf8.DecodeLastRuneInString(markdown[:i])
+	return i == 0 || !(unicode.IsLetter(prev) || unicode.IsDigit(prev))
+}
+
+// autolinkLength returns the length of a Markdown autolink such as
+// "<https://example.com>" at the start of s, or 0
+func autolinkLength(s string) int {
+	if !strings.HasPrefix(s, "<http://") && !strings.HasPrefix(s, "<https://") && !strings.HasPrefix(s, "<mailto:") {
+		return 0
+	}
+	end := strings.IndexAny(s, "> \n")
+	if end == -1 || s[end] != '>' {
+		return 0
+	}
+	return end + 1
+}
+
+// codeSpanLength returns the length of the code span at the start of s, or
+// the length of the backtick run if it is not closed
+func codeSpanLength(s string) int {
+	run := len(s) - len(strings.TrimLeft(s, "`"))
+
+	for i := run; i < len(s); {
+		j := strings.IndexByte(s[i:], '`')
+		if j == -1 {
+			break
+		}
+		j += i
+
+		// The closing run must have exactly the same length
+		k := j
+		for k < len(s) && s[k] == '`' {
+			k++
+		}
+		if k-j == run {
+			return k
+		}
+		i = k
+	}
+
+	return run
+}
+
+// markdownLink is an inline Markdown link
+type markdownLink struct {
+	text  string
+	href  string
+	title string // including the leading space and quotes
+}
+
+// parseMarkdownLink parses the inline link at the start of s and returns
+// it with its length, or a zero length if s does not start with a link
+func parseMarkdownLink(s string) (markdownLink, int) {
+	// Find the bracket closing the link text
+	depth := 0
+	end := -1
+	for i := 0; i < len(s) && end == -1; i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				end = i
+			}
+		case '\n':
+			if i+1 < len(s) && s[i+1] == '\n' {
+				return markdownLink{}, 0
+			}
+		}
+	}
+	if end == -1 || !strings.HasPrefix(s[end:], "](") {
+		return markdownLink{}, 0
+	}
+
+	dest := s[end+2:]
+	hrefLen := linkDestinationLength(dest)
+	closing := closingParenIndex(dest)
+	if closing >= len(dest) || dest[closing] != ')' || closing < hrefLen {
+		return markdownLink{}, 0
+	}
+
+	return markdownLink{
+		text:  s[1:end],
+		href:  dest[:hrefLen],
+		title: dest[hrefLen:closing],
+	}, end + 2 + closing + 1
+}
+
+// linkDestinationLength returns the length of a link destination up to an
+// optional title or the closing parenthesis
+func linkDestinationLength(s string) int {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '(':
+			depth++
+		case ')':
+			if depth == 0 {
+				return i
+			}
+			depth--
+		case ' ', '\n':
+			return i
+		}
+	}
+	return len(s)
+}
+
+// closingParenIndex returns the index of the parenthesis closing a link
+// destination and optional title, or len(s) if it is missing
+func closingParenIndex(s string) int {
+	depth := 0
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			if depth == 0 {
+				return i
+			}
+			depth--
+		case c == '\n' && i+1 < len(s) && s[i+1] == '\n':
+			return i
+		}
+	}
+	return len(s)
+}
+
+// jsxTagLength returns the length of a component tag emitted by the
+// converter at the start of s, or 0 if s does not start with one. Quoted
+// attribute values and {expressions} are skipped when looking for the end
+// of the tag.
+func jsxTagLength(s string) int {
+	m := jsxTagNameRe.FindStringSubmatch(s)
+	if m == nil || !isJSXTag(m[1]) {
+		return 0
+	}
+
+	depth := 0
+	var quote byte
+	for i := len(m[0]); i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '{':
+			depth++
+		case c == '}':
+			depth--
+		case c == '>' && depth == 0:
+			return i + 1
+		}
+	}
+
+	return 0
+}
+
+// isJSXTag reports whether name is a tag the converter emits on purpose
+func isJSXTag(name string) bool {
+	if name == "div" {
+		// Accordion content slots
+		return true
+	}
+	for _, component := range componentImports {
+		if component.name == name {
+			return true
+		}
+	}
+	return false
+}
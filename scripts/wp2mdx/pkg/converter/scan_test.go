@@ -0,0 +1,188 @@
+package converter
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestScanMarkdown(t *testing.T) {
+	markdown := "import Image from './Image.astro'\n" +
+		"## Darm {#id}\n" +
+		"Der *Darm* \\{ `a{b}` [Text](/ziel \"Titel\") ![Alt](/a.jpg){position=left}\n" +
+		"<Image src={img} alt=\"a > b\" /> <https://example.com> siehe https://example.com/x_y![B](/b.jpg)\n" +
+		"```\ncode {x}\n```\n"
+
+	type want struct {
+		kind segmentKind
+		raw  string
+	}
+	wants := []want{
+		{segText, "import Image from './Image.astro'\n"},
+		{segText, "## Darm {#id}\n"},
+		{segText, "Der "},
+		{segMarker, "*"},
+		{segText, "Darm"},
+		{segMarker, "*"},
+		{segText, " "},
+		{segEscape, "\\{"},
+		{segText, " "},
+		{segCode, "`a{b}`"},
+		{segText, " "},
+		{segLink, "[Text](/ziel \"Titel\")"},
+		{segText, " "},
+		{segImage, "![Alt](/a.jpg){position=left}"},
+		{segText, "\n"},
+		{segTag, "<Image src={img} alt=\"a > b\" />"},
+		{segText, " "},
+		{segURL, "<https://example.com>"},
+		{segText, " siehe "},
+		{segURL, "https://example.com/x_y"},
+		{segImage, "![B](/b.jpg)"},
+		{segText, "\n"},
+		{segCode, "```\n"},
+		{segCode, "code {x}\n"},
+		{segCode, "```\n"},
+	}
+
+	segments := scanMarkdown(markdown)
+	var got []want
+	var sb strings.Builder
+	for _, seg := range segments {
+		got = append(got, want{seg.kind, seg.raw})
+		sb.WriteString(seg.raw)
+	}
+	if sb.String() != markdown {
+		t.Fatalf("segments add up to %q, want %q", sb.String(), markdown)
+	}
+	if len(got) != len(wants) {
+		t.Fatalf("scanMarkdown() returned %d segments, want %d:\n%q", len(got), len(wants), got)
+	}
+	for i := range wants {
+		if got[i] != wants[i] {
+			t.Errorf("segment %d = %q, want %q", i, got[i], wants[i])
+		}
+	}
+
+	if !segments[1].heading || segments[2].heading {
+		t.Errorf("only the heading line should be marked as heading")
+	}
+	if link := segments[11].link; link.text != "Text" || link.href != "/ziel" || link.title != ` "Titel"` {
+		t.Errorf("link = %+v", link)
+	}
+	if segments[15].name != "Image" {
+		t.Errorf("tag name = %q, want Image", segments[15].name)
+	}
+}
+
+func TestScanMarkdownUnclosedLink(t *testing.T) {
+	// A blank line ends the paragraph before the closing parenthesis
+	markdown := "[Text](/ziel\n\nweiter)"
+	for _, seg := range scanMarkdown(markdown) {
+		if seg.kind == segLink {
+			t.Errorf("unexpected link %q", seg.raw)
+		}
+	}
+}
+
+func TestEscapeMDX(t *testing.T) {
+	tests := []struct {
+		name     string
+		markdown string
+		want     string
+	}{
+		{"text", "a {b} < c", `a \{b\} \< c`},
+		{"code", "`{a}`\n```\n{b}\n```\n", "`{a}`\n```\n{b}\n```\n"},
+		{"already escaped", `\{a\}`, `\{a\}`},
+		{"link", `[a {b}](/x?y={z} "t {u}")`, `[a \{b\}](/x?y={z} "t \{u\}")`},
+		{"image", "![a <b>](/x.jpg){position=left}", `![a \<b>](/x.jpg){position=left}`},
+		{"tag", `<Image src={img} alt="{a}" />`, `<Image src={img} alt="{a}" />`},
+		{"autolink", "<https://example.com>", `\<https://example.com>`},
+		{"url", `https://example.com/a\_b{c}`, `https://example.com/a\_b\{c\}`},
+		{"stray destination", "](}", `](\}`},
+		{"stray position", "a {position=left}", `a \{position=left\}`},
+		{"esm", "import Darm from './Darm.astro'\n*a*\nexport const b = 1\n", "&#105;mport Darm from './Darm.astro'\n*a*\n&#101;xport const b = 1\n"},
+		{"esm after a segment", "`a`\nexport {b}\n", "`a`\n&#101;xport \\{b\\}\n"},
+		{"not esm", "Wir import ieren.\n  export b\nimporte", "Wir import ieren.\n  export b\nimporte"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := EscapeMDX(tt.markdown); got != tt.want {
+				t.Errorf("EscapeMDX(%q) = %q, want %q", tt.markdown, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRewriteLinksFunc(t *testing.T) {
+	resolve := func(href string) (string, bool) {
+		return "/posts/darm/", href == "https://example.com/darm/"
+	}
+	markdown := "[Darm](https://example.com/darm/ \"Über den Darm\") " +
+		"[![Bild](https://example.com/darm/)](https://example.com/darm/) " +
+		"`[Code](https://example.com/darm/)` [Extern](https://example.org/) " +
+		"https://example.com/darm/[Darm](https://example.com/darm/)"
+
+	got := RewriteLinksFunc(markdown, resolve, false)
+	want := "[Darm](/posts/darm/ \"Über den Darm\") " +
+		"[![Bild](https://example.com/darm/)](/posts/darm/) " +
+		"`[Code](https://example.com/darm/)` [Extern](https://example.org/) " +
+		"https://example.com/darm/[Darm](/posts/darm/)"
+	if got != want {
+		t.Errorf("RewriteLinksFunc() = %q, want %q", got, want)
+	}
+
+	got = RewriteLinksFunc("[Darm](https://example.com/darm/ \"Über den \\\"Darm\\\"\")", resolve, true)
+	want = `<InternalLink href="/posts/darm/" title="Über den &quot;Darm&quot;">Darm</InternalLink>`
+	if got != want {
+		t.Errorf("RewriteLinksFunc() = %q, want %q", got, want)
+	}
+}
+
+func TestEstimateReadingTime(t *testing.T) {
+	markdown := "## Der Darm\n\n" +
+		"Ein [Link mit Text](https://example.com/sehr/lange/url) und `code` sowie\n" +
+		"```\nviel code hier\n```\n" +
+		"![Alt Text](/a.jpg){position=left}\n" +
+		"<Image src={img} alt=\"Bild\" />\n" +
+		"<https://example.com> \\*Ende\\*\n"
+
+	got := EstimateReadingTime(markdown, 200, 12)
+	// Der Darm Ein Link mit Text und sowie <https://example.com> *Ende*
+	if got.Words != 10 || got.Images != 2 || got.Minutes != 1 {
+		t.Errorf("EstimateReadingTime() = %+v, want 10 words and 2 images in 1 minute", got)
+	}
+
+	if got := EstimateReadingTime("", 200, 12); got.Minutes != 0 {
+		t.Errorf("EstimateReadingTime(\"\") = %+v, want 0 minutes", got)
+	}
+}
+
+func TestLinkTermsFunc(t *testing.T) {
+	link := func(text string) string {
+		return strings.ReplaceAll(text, "Darm", "<GlossaryTooltip>Darm</GlossaryTooltip>")
+	}
+
+	tests := []struct {
+		name     string
+		markdown string
+		want     string
+	}{
+		{"prose", "Der Darm.", "Der <GlossaryTooltip>Darm</GlossaryTooltip>."},
+		{"heading", "## Darm\nDarm", "## Darm\n<GlossaryTooltip>Darm</GlossaryTooltip>"},
+		{"code", "`Darm`", "`Darm`"},
+		{"link", "[Darm](/darm/)", "[Darm](/darm/)"},
+		{"image", "![Darm](/darm.jpg)", "![Darm](/darm.jpg)"},
+		{"link component", `<InternalLink href="/x">Der Darm</InternalLink> Darm`, `<InternalLink href="/x">Der Darm</InternalLink> <GlossaryTooltip>Darm</GlossaryTooltip>`},
+		{"autolink", "<https://example.com/Darm>", "<https://example.com/Darm>"},
+		{"bare url", "https://example.com/Darm Darm", "https://example.com/Darm <GlossaryTooltip>Darm</GlossaryTooltip>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := LinkTermsFunc(tt.markdown, link); got != tt.want {
+				t.Errorf("LinkTermsFunc(%q) = %q, want %q", tt.markdown, got, tt.want)
+			}
+		})
+	}
+}
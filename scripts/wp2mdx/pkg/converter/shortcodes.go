@@ -0,0 +1,407 @@
+package converter
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// Shortcode is a WordPress shortcode such as [caption id="1"]...[/caption]
+type Shortcode struct {
+	Name string
+	// Attrs holds named attributes; positional attributes are stored under
+	// their index ("0", "1", ...)
+	Attrs map[string]string
+	// Content is the raw text between the opening and closing tag
+	Content     string
+	SelfClosing bool
+	Raw         string
+}
+
+// Attr returns a named attribute or the empty string
+func (s *Shortcode) Attr(key string) string {
+	return s.Attrs[key]
+}
+
+// shortcodeNode is either literal text or a shortcode
+type shortcodeNode struct {
+	text      string
+	shortcode *Shortcode
+}
+
+// shortcodeHandler converts a shortcode to MDX
+type shortcodeHandler func(c *Converter, sc *Shortcode, ctx *renderContext) (string, error)
+
+// shortcodeHandlers maps shortcode names to handlers. Unknown shortcodes are
+// reported and left as literal text.
+var shortcodeHandlers map[string]shortcodeHandler
+
+func init() {
+	shortcodeHandlers = map[string]shortcodeHandler{
+		"caption":    handleCaption,
+		"wp_caption": handleCaption,
+		"gallery":    handleGallery,
+		"embed":      handleEmbed,
+		"video":      handleMedia,
+		"audio":      handleMedia,
+		"playlist":   handleNothing,
+	}
+}
+
+// shortcodeNameRe matches the names the tokenizer accepts. Names are
+// lowercase by convention, which keeps bracketed prose from being reported.
+var shortcodeNameRe = regexp.MustCompile(`^[a-z][a-z0-9_-]*$`)
+
+// parseShortcodes tokenizes text into literal text and shortcodes. It
+// handles quoted attributes, self-closing tags, nesting of the same
+// shortcode and [[escaped]] shortcodes, which are returned as literal text.
+func parseShortcodes(text string) []shortcodeNode {
+	var nodes []shortcodeNode
+	last := 0
+
+	for i := 0; i < len(text); {
+		if text[i] != '[' {
+			i++
+			continue
+		}
+
+		// [[name]] renders the shortcode literally
+		if strings.HasPrefix(text[i:], "[[") {
+			if tag, ok := parseShortcodeTag(text, i+1); ok && !tag.closer &&
+				strings.HasPrefix(text[tag.end:], "]") {
+				nodes = appendText(nodes, text[last:i]+text[i+1:tag.end])
+				i = tag.end + 1
+				last = i
+				continue
+			}
+		}
+
+		tag, ok := parseShortcodeTag(text, i)
+		if !ok || tag.closer {
+			i++
+			continue
+		}
+
+		sc := &Shortcode{Name: tag.name, Attrs: tag.attrs, SelfClosing: tag.selfClosing}
+		end := tag.end
+		if !tag.selfClosing {
+			if closeStart, closeEnd, found := findShortcodeClose(text, tag.end, tag.name); found {
+				sc.Content = text[tag.end:closeStart]
+				end = closeEnd
+			} else {
+				// Without a closing tag the shortcode stands alone
+				sc.SelfClosing = true
+			}
+		}
+		sc.Raw = text[i:end]
+
+		nodes = appendText(nodes, text[last:i])
+		nodes = append(nodes, shortcodeNode{shortcode: sc})
+		i = end
+		last = end
+	}
+
+	return appendText(nodes, text[last:])
+}
+
+func appendText(nodes []shortcodeNode, text string) []shortcodeNode {
+	if text == "" {
+		return nodes
+	}
+	return append(nodes, shortcodeNode{text: text})
+}
+
+// shortcodeTag is a parsed opening or closing tag
+type shortcodeTag struct {
+	name        string
+	attrs       map[string]string
+	closer      bool
+	selfClosing bool
+	end         int
+}
+
+// parseShortcodeTag parses the tag starting at text[start] == '['
+func parseShortcodeTag(text string, start int) (shortcodeTag, bool) {
+	tag := shortcodeTag{}
+	i := start + 1
+
+	if i < len(text) && text[i] == '/' {
+		tag.closer = true
+		i++
+	}
+
+	nameStart := i
+	for i < len(text) && (isShortcodeNameByte(text[i])) {
+		i++
+	}
+	tag.name = text[nameStart:i]
+	if !shortcodeNameRe.MatchString(tag.name) {
+		return tag, false
+	}
+	if i < len(text) && text[i] != ']' && text[i] != '/' && !unicode.IsSpace(rune(text[i])) {
+		return tag, false
+	}
+
+	// Find the end of the tag, skipping brackets inside quoted values
+	attrStart := i
+	var quote byte
+	for ; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '[':
+			return tag, false
+		case c == ']':
+			body := strings.TrimSpace(text[attrStart:i])
+			if strings.HasSuffix(body, "/") {
+				tag.selfClosing = true
+				body = strings.TrimSpace(strings.TrimSuffix(body, "/"))
+			}
+			if tag.closer && body != "" {
+				return tag, false
+			}
+			tag.attrs = parseShortcodeAttrs(body)
+			tag.end = i + 1
+			return tag, true
+		}
+	}
+
+	return tag, false
+}
+
+func isShortcodeNameByte(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-'
+}
+
+// shortcodeAttrRe matches name="value", name='value', name=value and
+// positional "value", 'value' or value attributes
+var shortcodeAttrRe = regexp.MustCompile(`([\w-]+)\s*=\s*"([^"]*)"|([\w-]+)\s*=\s*'([^']*)'|([\w-]+)\s*=\s*([^\s'"]+)|"([^"]*)"|'([^']*)'|(\S+)`)
+
+// parseShortcodeAttrs parses the attribute part of a shortcode tag
+func parseShortcodeAttrs(body string) map[string]string {
+	attrs := make(map[string]string)
+	positional := 0
+
+	for _, m := range shortcodeAttrRe.FindAllStringSubmatch(body, -1) {
+		switch {
+		case m[1] != "":
+			attrs[strings.ToLower(m[1])] = m[2]
+		case m[3] != "":
+			attrs[strings.ToLower(m[3])] = m[4]
+		case m[5] != "":
+			attrs[strings.ToLower(m[5])] = m[6]
+		default:
+			value := m[7] + m[8] + m[9]
+			attrs[strconv.Itoa(positional)] = value
+			positional++
+		}
+	}
+
+	return attrs
+}
+
+// findShortcodeClose finds the closing tag matching an opening tag of name,
+// accounting for nested shortcodes of the same name
+func findShortcodeClose(text string, from int, name string) (start, end int, found bool) {
+	depth := 0
+	for i := from; i < len(text); i++ {
+		if text[i] != '[' {
+			continue
+		}
+		tag, ok := parseShortcodeTag(text, i)
+		if !ok || tag.name != name {
+			continue
+		}
+		switch {
+		case tag.closer && depth == 0:
+			return i, tag.end, true
+		case tag.closer:
+			depth--
+		case !tag.selfClosing:
+			depth++
+		}
+		i = tag.end - 1
+	}
+	return 0, 0, false
+}
+
+// expandShortcodes replaces registered shortcodes in HTML with placeholders
+// and returns the MDX each placeholder stands for. The placeholders survive
+// the HTML to Markdown conversion unchanged and are substituted afterwards.
+func (c *Converter) expandShortcodes(html string, ctx *renderContext) (string, []string, error) {
+	if !strings.Contains(html, "[") {
+		return html, nil, nil
+	}
+
+	var sb strings.Builder
+	var replacements []string
+	if err := c.writeShortcodes(&sb, &replacements, html, ctx); err != nil {
+		return "", nil, err
+	}
+
+	return sb.String(), replacements, nil
+}
+
+// writeShortcodes writes html to sb, replacing registered shortcodes with
+// placeholders. Like WordPress, it leaves unknown shortcodes as literal
+// text, which keeps editorial brackets such as "[sic]" intact; shortcodes
+// nested inside them are still expanded.
+func (c *Converter) writeShortcodes(sb *strings.Builder, replacements *[]string, html string, ctx *renderContext) error {
+	for _, node := range parseShortcodes(html) {
+		sc := node.shortcode
+		if sc == nil {
+			sb.WriteString(node.text)
+			continue
+		}
+
+		handler, ok := shortcodeHandlers[strings.ToLower(sc.Name)]
+		if !ok {
+			ctx.warnOnce("unknown shortcode [%s] left as text", sc.Name)
+			if sc.SelfClosing {
+				sb.WriteString(sc.Raw)
+				continue
+			}
+			open, _ := parseShortcodeTag(sc.Raw, 0)
+			sb.WriteString(sc.Raw[:open.end])
+			if err := c.writeShortcodes(sb, replacements, sc.Content, ctx); err != nil {
+				return err
+			}
+			sb.WriteString(sc.Raw[open.end+len(sc.Content):])
+			continue
+		}
+
+		mdx, err := handler(c, sc, ctx)
+		if err != nil {
+			return err
+		}
+		sb.WriteString(shortcodePlaceholder(len(*replacements)))
+		*replacements = append(*replacements, mdx)
+	}
+
+	return nil
+}
+
+// shortcodePlaceholder returns a token that Markdown conversion leaves intact
+func shortcodePlaceholder(i int) string {
+	return fmt.Sprintf("WPMDXSHORTCODE%dX", i)
+}
+
+// handleNothing drops shortcodes that have no equivalent on the site
+func handleNothing(c *Converter, sc *Shortcode, ctx *renderContext) (string, error) {
+	ctx.warnOnce("shortcode [%s] not supported", sc.Name)
+	return "", nil
+}
+
+// handleCaption renders [caption]<img ...> Caption text[/caption] as an
+// image with a caption
+func handleCaption(c *Converter, sc *Shortcode, ctx *renderContext) (string, error) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(sc.Content))
+	if err != nil {
+		return "", err
+	}
+
+	img := doc.Find("img").First()
+	src, ok := img.Attr("src")
+	if !ok {
+		return c.convertHTML(sc.Content, ctx)
+	}
+	alt, _ := img.Attr("alt")
+
+	// The caption is either an attribute or the text following the image
+	caption := sc.Attr("caption")
+	if caption == "" {
+		doc.Find("img").Remove()
+		caption = doc.Text()
+	}
+
+	position := "center"
+	switch sc.Attr("align") {
+	case "alignleft":
+		position = "left"
+	case "alignright":
+		position = "right"
+	}
+
+	return "\n\n" + imageMarkdown(alt, src, strings.TrimSpace(caption), position) + "\n\n", nil
+}
+
+// handleGallery renders [gallery ids="1,2,3"] as its images in order. The
+// site has no gallery component, so images are placed one after another.
+func handleGallery(c *Converter, sc *Shortcode, ctx *renderContext) (string, error) {
+	ids := parseIDList(sc.Attr("ids"))
+	if len(ids) == 0 {
+		ctx.warnOnce("gallery without ids not included")
+		return "", nil
+	}
+
+	var images []string
+	for _, id := range ids {
+		attachment := c.attachments.ByID(id)
+		if attachment == nil {
+			ctx.warnf("gallery attachment %d not found", id)
+			continue
+		}
+		alt := attachment.Meta["_wp_attachment_image_alt"]
+		images = append(images, imageMarkdown(alt, attachment.URL, attachment.Caption, "center"))
+	}
+
+	if len(images) == 0 {
+		return "", nil
+	}
+
+	return "\n\n" + strings.Join(images, "\n\n") + "\n\n", nil
+}
+
+// handleEmbed renders [embed]url[/embed] as a link
+func handleEmbed(c *Converter, sc *Shortcode, ctx *renderContext) (string, error) {
+	url := strings.TrimSpace(sc.Content)
+	if url == "" {
+		url = sc.Attr("src")
+	}
+	if url == "" {
+		return "", nil
+	}
+	return fmt.Sprintf("[%s](%s)", url, url), nil
+}
+
+// handleMedia renders [video] and [audio] as a link to their source
+func handleMedia(c *Converter, sc *Shortcode, ctx *renderContext) (string, error) {
+	for _, key := range []string{"src", "mp4", "webm", "ogv", "mp3", "m4a", "ogg", "wav"} {
+		if url := sc.Attr(key); url != "" {
+			return fmt.Sprintf("[%s](%s)", url, url), nil
+		}
+	}
+	return handleEmbed(c, sc, ctx)
+}
+
+// GalleryAttachmentIDs returns the attachment IDs of all [gallery] shortcodes
+// in content, in order
+func GalleryAttachmentIDs(content string) []int {
+	var ids []int
+	for _, node := range parseShortcodes(content) {
+		if node.shortcode != nil && node.shortcode.Name == "gallery" {
+			ids = append(ids, parseIDList(node.shortcode.Attr("ids"))...)
+		}
+	}
+	return ids
+}
+
+// parseIDList parses a comma-separated list of IDs, skipping invalid entries
+func parseIDList(list string) []int {
+	var ids []int
+	for _, field := range strings.Split(list, ",") {
+		if id, err := strconv.Atoi(strings.TrimSpace(field)); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
@@ -0,0 +1,152 @@
+package converter
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aequinox/gesundes-leben/wp2mdx/pkg/models"
+	"github.com/aequinox/gesundes-leben/wp2mdx/pkg/parser"
+)
+
+func TestParseShortcodes(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []shortcodeNode
+	}{
+		{
+			name: "quoted attributes",
+			text: `Vor [caption id="a [1]" align='alignleft' width=300 "frei"]Bild[/caption] nach`,
+			want: []shortcodeNode{
+				{text: "Vor "},
+				{shortcode: &Shortcode{
+					Name:    "caption",
+					Attrs:   map[string]string{"id": "a [1]", "align": "alignleft", "width": "300", "0": "frei"},
+					Content: "Bild",
+					Raw:     `[caption id="a [1]" align='alignleft' width=300 "frei"]Bild[/caption]`,
+				}},
+				{text: " nach"},
+			},
+		},
+		{
+			name: "self-closing",
+			text: `[gallery ids="1,2" /][video src=a.mp4]`,
+			want: []shortcodeNode{
+				{shortcode: &Shortcode{Name: "gallery", Attrs: map[string]string{"ids": "1,2"}, SelfClosing: true, Raw: `[gallery ids="1,2" /]`}},
+				{shortcode: &Shortcode{Name: "video", Attrs: map[string]string{"src": "a.mp4"}, SelfClosing: true, Raw: `[video src=a.mp4]`}},
+			},
+		},
+		{
+			name: "nesting",
+			text: `[box][box]innen[/box] außen[/box]`,
+			want: []shortcodeNode{
+				{shortcode: &Shortcode{
+					Name:    "box",
+					Attrs:   map[string]string{},
+					Content: "[box]innen[/box] außen",
+					Raw:     `[box][box]innen[/box] außen[/box]`,
+				}},
+			},
+		},
+		{
+			name: "escaped",
+			text: `[[gallery ids="1"]]`,
+			want: []shortcodeNode{{text: `[gallery ids="1"]`}},
+		},
+		{
+			name: "not a shortcode",
+			text: `[Anmerkung] [1] [ a]`,
+			want: []shortcodeNode{{text: `[Anmerkung] [1] [ a]`}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseShortcodes(tt.text)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseShortcodes(%q) =\n%s\nwant\n%s", tt.text, formatNodes(got), formatNodes(tt.want))
+			}
+		})
+	}
+}
+
+// formatNodes renders nodes one per line for test failures
+func formatNodes(nodes []shortcodeNode) string {
+	var sb strings.Builder
+	for _, node := range nodes {
+		if node.shortcode != nil {
+			fmt.Fprintf(&sb, "  shortcode %+v\n", *node.shortcode)
+		} else {
+			fmt.Fprintf(&sb, "  text %q\n", node.text)
+		}
+	}
+	return sb.String()
+}
+
+func TestConvertShortcodes(t *testing.T) {
+	attachments := parser.NewAttachmentIndex()
+	for _, item := range []*models.Item{
+		{PostID: 11, PostType: "attachment", AttachmentURL: "https://example.com/wp-content/uploads/2024/03/darm.jpg"},
+		{PostID: 12, PostType: "attachment", AttachmentURL: "https://example.com/wp-content/uploads/2024/03/hirn.jpg"},
+	} {
+		attachments.Add(item)
+	}
+
+	tests := []struct {
+		name     string
+		html     string
+		contains []string
+		excludes []string
+		warning  string
+	}{
+		{
+			name:     "caption",
+			html:     `<p>[caption id="attachment_11" align="alignright"]<img src="https://example.com/darm.jpg" alt="Darm"> Der Darm[/caption]</p>`,
+			contains: []string{`![Darm](https://example.com/darm.jpg "Der Darm")`, "position=right"},
+			excludes: []string{"[caption"},
+		},
+		{
+			name:     "gallery",
+			html:     `<p>[gallery ids="12, 11, 99"]</p>`,
+			contains: []string{"hirn.jpg", "darm.jpg"},
+			excludes: []string{"[gallery"},
+			warning:  "gallery attachment 99 not found",
+		},
+		{
+			name:     "unknown shortcodes in prose",
+			html:     `<p>Sie [die Forscher] fanden heraus, dass [sic] das stimmt.</p>`,
+			contains: []string{`Sie \[die Forscher\] fanden heraus, dass \[sic\] das stimmt.`},
+			warning:  "unknown shortcode [die] left as text",
+		},
+		{
+			name:     "known shortcode inside an unknown one",
+			html:     `<p>[row][embed]https://example.com/v[/embed][/row]</p>`,
+			contains: []string{`\[row\][https://example.com/v](https://example.com/v)\[/row\]`},
+			warning:  "unknown shortcode [row] left as text",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := New(attachments).ConvertContent(tt.html)
+			if err != nil {
+				t.Fatalf("ConvertContent() error = %v", err)
+			}
+			for _, s := range tt.contains {
+				if !strings.Contains(result.Markdown, s) {
+					t.Errorf("output should contain %q, got:\n%s", s, result.Markdown)
+				}
+			}
+			for _, s := range tt.excludes {
+				if strings.Contains(result.Markdown, s) {
+					t.Errorf("output should not contain %q, got:\n%s", s, result.Markdown)
+				}
+			}
+			if tt.warning != "" && !strings.Contains(strings.Join(result.Warnings, "\n"), tt.warning) {
+				t.Errorf("warnings = %v, want %q", result.Warnings, tt.warning)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package frontmatter
+
+import (
+	"html"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/aequinox/gesundes-leben/wp2mdx/pkg/keywords"
+)
+
+// Target length of generated descriptions in characters
+const (
+	descriptionMinLength = 120
+	descriptionMaxLength = 160
+)
+
+// ellipsis marks a description cut within a sentence
+const ellipsis = "…"
+
+// hashtagRe matches social media hashtags such as "#sogehtgesund", also
+// when glued to the end of a sentence, but not URL fragments
+var hashtagRe = regexp.MustCompile(`(^|[^\p{L}\p{N}_/])#[\p{L}\p{N}_]+`)
+
+// descriptionText returns the prose a description is built from: the
+// paragraphs of the post, or all of its text if it has none. Headings,
+// captions and quotes are left out.
+func descriptionText(content string) string {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(content))
+	if err != nil {
+		return plainText(content)
+	}
+
+	var paragraphs []string
+	doc.Find("p").Each(func(_ int, p *goquery.Selection) {
+		if p.ParentsFiltered("figure, blockquote, details, table").Length() > 0 {
+			return
+		}
+		paragraph, err := goquery.OuterHtml(p)
+		if err != nil {
+			return
+		}
+		if text := strings.TrimSpace(plainText(paragraph)); text != "" {
+			paragraphs = append(paragraphs, text)
+		}
+	})
+
+	if len(paragraphs) == 0 {
+		return plainText(content)
+	}
+	return strings.Join(paragraphs, " ")
+}
+
+// plainText strips markup and shortcodes and decodes entities. Excerpts in
+// WordPress exports are often entity-encoded twice, so entities left after
+// parsing are decoded again.
+func plainText(content string) string {
+	return html.UnescapeString(keywords.PlainText(content))
+}
+
+// buildDescription shortens plain text to at most descriptionMaxLength
+// characters. It cuts after the last sentence ending within the target
+// range, otherwise at the last word boundary with an ellipsis.
+func buildDescription(text string) string {
+	text = hashtagRe.ReplaceAllString(text, "$1")
+	text = strings.Join(strings.Fields(text), " ")
+
+	runes := []rune(text)
+	if len(runes) <= descriptionMaxLength {
+		return text
+	}
+
+	// The last complete sentence that fits the target range
+	for i := descriptionMaxLength - 1; i >= descriptionMinLength-1; i-- {
+		if isSentenceEnd(runes, i) {
+			return string(runes[:i+1])
+		}
+	}
+
+	// The last word boundary that leaves room for the ellipsis
+	limit := descriptionMaxLength - len([]rune(ellipsis))
+	for i := limit; i >= descriptionMinLength; i-- {
+		if runes[i] == ' ' {
+			cut := strings.TrimRightFunc(string(runes[:i]), func(r rune) bool {
+				return unicode.IsPunct(r) || unicode.IsSpace(r)
+			})
+			return cut + ellipsis
+		}
+	}
+
+	return string(runes[:limit]) + ellipsis
+}
+
+// isSentenceEnd reports whether the rune at i ends a sentence: a full stop,
+// question or exclamation mark after a word, followed by a space and a
+// capital letter. Abbreviations like "z. B." and ordinals like "1. Mai"
+// don't count.
+func isSentenceEnd(runes []rune, i int) bool {
+	switch runes[i] {
+	case '.', '!', '?':
+	default:
+		return false
+	}
+	if i+2 >= len(runes) || runes[i+1] != ' ' || !unicode.IsUpper(runes[i+2]) {
+		return false
+	}
+	// A single letter or digit before the stop is an abbreviation or number
+	return i >= 2 && unicode.IsLetter(runes[i-1]) && unicode.IsLetter(runes[i-2])
+}
@@ -2,43 +2,93 @@ package frontmatter
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/aequinox/gesundes-leben/wp2mdx/pkg/authors"
+	"github.com/aequinox/gesundes-leben/wp2mdx/pkg/classify"
 	"github.com/aequinox/gesundes-leben/wp2mdx/pkg/config"
+	"github.com/aequinox/gesundes-leben/wp2mdx/pkg/converter"
+	"github.com/aequinox/gesundes-leben/wp2mdx/pkg/keywords"
 	"github.com/aequinox/gesundes-leben/wp2mdx/pkg/models"
 	"github.com/aequinox/gesundes-leben/wp2mdx/pkg/parser"
+	"github.com/aequinox/gesundes-leben/wp2mdx/pkg/references"
 	"gopkg.in/yaml.v3"
 )
 
 // Generator generates frontmatter from WordPress posts
 type Generator struct {
-	config *config.Config
+	config     *config.Config
+	corpus     *keywords.Corpus
+	classifier *classify.Classifier
+	authors    *authors.Resolver
+	references *references.Library
 }
 
 // New creates a new frontmatter generator
 func New(cfg *config.Config) *Generator {
 	return &Generator{
-		config: cfg,
+		config:     cfg,
+		classifier: classify.New(classify.DefaultRules()),
 	}
 }
 
+// SetKeywordCorpus sets the corpus keywords are scored against. Without
+// one, keywords are ranked by their frequency in the post alone.
+func (g *Generator) SetKeywordCorpus(corpus *keywords.Corpus) {
+	g.corpus = corpus
+}
+
+// SetClassifier replaces the default group classification rules
+func (g *Generator) SetClassifier(classifier *classify.Classifier) {
+	g.classifier = classifier
+}
+
+// SetAuthorResolver sets the resolver matching WordPress logins to the
+// authors collection. Without one, only the author mapping is applied.
+func (g *Generator) SetAuthorResolver(resolver *authors.Resolver) {
+	g.authors = resolver
+}
+
+// SetReferenceLibrary sets the references collection citations are linked
+// to. Without one, references are not extracted.
+func (g *Generator) SetReferenceLibrary(library *references.Library) {
+	g.references = library
+}
+
+// Classify determines the group of a WordPress item. Category overrides
+// match both the WordPress and the mapped blog category.
+func (g *Generator) Classify(item *models.Item) classify.Result {
+	categories := parser.GetCategories(item)
+	return g.classifier.Classify(classify.Input{
+		ID:         item.PostID,
+		Title:      item.Title,
+		Content:    item.Content,
+		Categories: append(categories, g.mapCategories(categories)...),
+		Tags:       parser.GetTags(item),
+	})
+}
+
 // Generate creates frontmatter for a post
 func (g *Generator) Generate(post *models.Post) (*models.Frontmatter, error) {
 	fm := &models.Frontmatter{
-		ID:          fmt.Sprintf("%d", post.RawItem.PostID),
-		Title:       post.Title,
-		Author:      g.getAuthor(post),
-		PubDatetime: post.PubDate.Format(time.RFC3339),
-		ModDatetime: post.ModDate.Format(time.RFC3339),
-		Description: g.getDescription(post),
-		Keywords:    post.Keywords,
-		Categories:  g.mapCategories(post.Categories),
-		Group:       post.Group,
-		Tags:        post.Tags,
-		Draft:       post.Draft,
-		Featured:    post.Featured,
-		Extra:       make(map[string]interface{}),
+		ID:           fmt.Sprintf("%d", post.RawItem.PostID),
+		Title:        post.Title,
+		Author:       post.Author,
+		PubDatetime:  post.PubDate.Format(time.RFC3339),
+		ModDatetime:  post.ModDate.Format(time.RFC3339),
+		Timezone:     g.config.Timezone,
+		Description:  g.getDescription(post),
+		CanonicalURL: post.SEO.CanonicalURL,
+		Keywords:     post.Keywords,
+		Categories:   g.mapCategories(post.Categories),
+		Group:        post.Group,
+		Tags:         post.Tags,
+		Draft:        post.Draft,
+		Featured:     post.Featured,
+		References:   post.References,
+		Extra:        make(map[string]interface{}),
 	}
 
 	// Add hero image if available
@@ -49,6 +99,13 @@ func (g *Generator) Generate(post *models.Post) (*models.Frontmatter, error) {
 		}
 	}
 
+	if post.OGImage != nil {
+		fm.OGImage = post.OGImage.LocalPath
+		if fm.OGImage == "" {
+			fm.OGImage = post.OGImage.URL
+		}
+	}
+
 	return fm, nil
 }
 
@@ -64,31 +121,35 @@ func (g *Generator) ToYAML(fm *models.Frontmatter) (string, error) {
 
 // getAuthor determines the author identifier
 func (g *Generator) getAuthor(post *models.Post) string {
-	if post.RawItem.Creator != "" {
+	if post.RawItem.Creator == "" {
+		return "healthy-life-author"
+	}
+	if g.authors == nil {
 		// Check for author mapping
 		return g.config.GetAuthor(post.RawItem.Creator)
 	}
-	return "healthy-life-author"
+
+	res := g.authors.Resolve(post.RawItem.Creator)
+	if !res.Resolved() {
+		post.Warnings = append(post.Warnings, fmt.Sprintf("author %q has no entry in the authors collection, using %q", res.Login, res.Slug))
+	}
+	return res.Slug
 }
 
-// getDescription generates a description from the excerpt or content
+// getDescription uses the SEO plugin's meta description, or generates one
+// from the excerpt or content. Meta descriptions may contain the excerpt's
+// markup through %%excerpt%% and are shortened like generated ones.
 func (g *Generator) getDescription(post *models.Post) string {
-	if post.Excerpt != "" {
-		// Clean up HTML from excerpt
-		desc := stripHTML(post.Excerpt)
-		if len(desc) > 160 {
-			desc = desc[:157] + "..."
-		}
-		return desc
+	if post.SEO.Description != "" {
+		return buildDescription(plainText(post.SEO.Description))
 	}
 
-	// Generate from content
-	content := stripHTML(post.Content)
-	if len(content) > 160 {
-		return content[:157] + "..."
+	source := descriptionText(post.Content)
+	if strings.TrimSpace(post.Excerpt) != "" {
+		source = plainText(post.Excerpt)
 	}
 
-	return content
+	return buildDescription(source)
 }
 
 // mapCategories maps WordPress categories to German blog categories
@@ -118,86 +179,17 @@ func (g *Generator) mapCategories(categories []string) []string {
 	return mapped
 }
 
-// stripHTML removes HTML tags from a string
-func stripHTML(html string) string {
-	// Simple HTML stripping - can be enhanced with goquery if needed
-	result := html
-
-	// Remove HTML tags
-	for {
-		start := strings.Index(result, "<")
-		if start == -1 {
-			break
-		}
-		end := strings.Index(result[start:], ">")
-		if end == -1 {
-			break
-		}
-		result = result[:start] + result[start+end+1:]
-	}
-
-	// Clean up whitespace
-	result = strings.TrimSpace(result)
-	result = strings.ReplaceAll(result, "\n", " ")
-	result = strings.ReplaceAll(result, "\r", "")
-
-	// Remove multiple spaces
-	for strings.Contains(result, "  ") {
-		result = strings.ReplaceAll(result, "  ", " ")
-	}
-
-	// Decode HTML entities
-	result = decodeHTMLEntities(result)
-
-	return result
-}
-
-// decodeHTMLEntities decodes common HTML entities
-func decodeHTMLEntities(s string) string {
-	entities := map[string]string{
-		"&nbsp;":   " ",
-		"&lt;":     "<",
-		"&gt;":     ">",
-		"&amp;":    "&",
-		"&quot;":   "\"",
-		"&#8211;":  "–",
-		"&#8212;":  "—",
-		"&#8216;":  "'",
-		"&#8217;":  "'",
-		"&#8220;":  "\"",
-		"&#8221;":  "\"",
-		"&auml;":   "ä",
-		"&ouml;":   "ö",
-		"&uuml;":   "ü",
-		"&Auml;":   "Ä",
-		"&Ouml;":   "Ö",
-		"&Uuml;":   "Ü",
-		"&szlig;":  "ß",
-		"&hellip;": "...",
-	}
-
-	result := s
-	for entity, replacement := range entities {
-		result = strings.ReplaceAll(result, entity, replacement)
-	}
-
-	return result
-}
-
 // BuildPost builds a complete Post model from a WordPress Item
-func (g *Generator) BuildPost(item *models.Item, allItems []models.Item) (*models.Post, error) {
-	pubDate, err := parser.ParseDate(item.PubDate)
+func (g *Generator) BuildPost(item *models.Item, attachments *parser.AttachmentIndex) (*models.Post, error) {
+	pubDate, modDate, err := parser.GetPostDates(item, g.config.Location())
 	if err != nil {
-		pubDate = time.Now()
+		return nil, err
 	}
 
-	// Use pub date as mod date if not available
-	modDate := pubDate
-
 	post := &models.Post{
 		ID:          fmt.Sprintf("%d", item.PostID),
 		Title:       item.Title,
-		Slug:        item.PostName,
+		Slug:        parser.GetSlug(item),
 		Content:     item.Content,
 		Excerpt:     item.Excerpt,
 		PubDate:     pubDate,
@@ -213,33 +205,131 @@ func (g *Generator) BuildPost(item *models.Item, allItems []models.Item) (*model
 		Frontmatter: make(map[string]interface{}),
 	}
 
-	// Generate slug if empty
-	if post.Slug == "" {
-		post.Slug = parser.GenerateSlug(post.Title)
+	// Prefer the focus keywords set in the SEO plugin
+	post.SEO = parser.GetSEOMeta(item)
+	if len(post.SEO.Keywords) > 0 {
+		post.Keywords = post.SEO.Keywords
+	} else {
+		doc := keywords.NewDocument(post.Title, post.Content, post.Tags)
+		post.Keywords = g.corpus.Keywords(doc, 10)
 	}
 
-	// Extract keywords
-	post.Keywords = parser.ExtractKeywords(post.Content, 10)
-
 	// Determine group
-	post.Group = parser.DetermineGroup(post.Title, post.Content)
+	group := g.Classify(item)
+	post.Group = group.Group
+	post.GroupConfidence = group.Confidence
+	post.GroupRule = group.Rule
+	if group.Rule == "default" {
+		post.Warnings = append(post.Warnings, fmt.Sprintf("no group rule matched, using default group %q", group.Group))
+	}
 
 	// Get author
 	post.Author = g.getAuthor(post)
 
+	if g.references != nil && g.config.ExtractReferences {
+		g.linkReferences(post)
+	}
+
 	// Get featured image
 	featuredImageID := parser.GetFeaturedImageID(item)
-	if featuredImageID != "" {
-		attachment := parser.FindAttachmentByID(allItems, featuredImageID)
+	if id, err := strconv.Atoi(featuredImageID); err == nil && attachments != nil {
+		attachment := attachments.ByID(id)
 		if attachment != nil {
 			post.HeroImage = &models.ImageRef{
-				ID:           featuredImageID,
-				URL:          attachment.GUID,
-				OriginalName: attachment.PostName,
-				Alt:          attachment.Title,
+				URL:          attachment.URL,
+				OriginalName: attachment.Name,
 			}
+			post.HeroImage.ApplyAttachment(attachment)
+			post.HeroImage.Alt = heroAlt(post, attachment)
 		}
 	}
 
+	post.OGImage = g.getOGImage(post, attachments)
+
+	g.checkImageAlt(post, attachments)
+
 	return post, nil
 }
+
+// linkReferences parses the citations of the post's references section
+// and sets the IDs of their entries in the references collection
+func (g *Generator) linkReferences(post *models.Post) {
+	refs, warnings, err := references.Extract(post.Content)
+	if err != nil {
+		post.Warnings = append(post.Warnings, fmt.Sprintf("failed to extract references: %v", err))
+		return
+	}
+	post.Warnings = append(post.Warnings, warnings...)
+
+	source := fmt.Sprintf("[%s] %s", post.ID, post.Title)
+	seen := make(map[string]bool)
+	for _, ref := range refs {
+		decision := g.references.Resolve(ref, source)
+		if !seen[decision.ID] {
+			seen[decision.ID] = true
+			post.References = append(post.References, decision.ID)
+		}
+	}
+}
+
+// getOGImage resolves the social sharing image set in the SEO plugin. It is
+// only returned if it differs from the hero image, which the site uses by
+// default.
+func (g *Generator) getOGImage(post *models.Post, attachments *parser.AttachmentIndex) *models.ImageRef {
+	attachment := attachments.ByID(post.SEO.OGImageID)
+	if attachment == nil && post.SEO.OGImageURL != "" {
+		attachment = attachments.ByURL(post.SEO.OGImageURL)
+	}
+
+	if attachment == nil {
+		if post.SEO.OGImageURL == "" {
+			return nil
+		}
+		return &models.ImageRef{URL: post.SEO.OGImageURL}
+	}
+
+	if post.HeroImage != nil && post.HeroImage.ID == strconv.Itoa(attachment.ID) {
+		return nil
+	}
+
+	img := &models.ImageRef{
+		URL:          attachment.URL,
+		OriginalName: attachment.Name,
+	}
+	img.ApplyAttachment(attachment)
+	return img
+}
+
+// heroAlt returns the alt text of the hero image: the attachment's alt
+// text, else its title. Both are often left at the file name, which does
+// not describe the image; then the post title is used and a warning
+// recorded.
+func heroAlt(post *models.Post, attachment *models.Attachment) string {
+	for _, alt := range []string{post.HeroImage.Alt, attachment.Title} {
+		if alt = strings.TrimSpace(alt); alt != "" && !parser.IsFilenameLikeAlt(alt) {
+			return alt
+		}
+	}
+	post.Warnings = append(post.Warnings, fmt.Sprintf("hero image %s has no alt text, using the post title", attachment.URL))
+	return post.Title
+}
+
+// checkImageAlt warns about content images whose alt text, after falling
+// back to the attachment, is still a file name
+func (g *Generator) checkImageAlt(post *models.Post, attachments *parser.AttachmentIndex) {
+	for _, img := range converter.ExtractImages(post.Content) {
+		alt := img.Alt
+		if alt == "" {
+			attachment := attachments.ByURL(img.URL)
+			if attachment == nil && img.AttachmentID != 0 {
+				attachment = attachments.ByID(img.AttachmentID)
+			}
+			if attachment != nil {
+				alt = attachment.Alt
+			}
+		}
+		if parser.IsFilenameLikeAlt(alt) {
+			post.Warnings = append(post.Warnings, fmt.Sprintf("image %s has filename-like alt text %q", img.URL, alt))
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package frontmatter
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/aequinox/gesundes-leben/wp2mdx/pkg/models"
+)
+
+func TestHeroAlt(t *testing.T) {
+	tests := []struct {
+		name       string
+		alt        string
+		title      string
+		want       string
+		wantWarned bool
+	}{
+		{"alt text", "Ein Glas Wasser", "Depositphotos_97583692", "Ein Glas Wasser", false},
+		{"title", "", "Ein Glas Wasser", "Ein Glas Wasser", false},
+		{"filename-like alt", "IMG_1234.jpg", "Ein Glas Wasser", "Ein Glas Wasser", false},
+		{"filename-like title", "", "Depositphotos_97583692", "Wasser trinken", true},
+		{"nothing", "", "", "Wasser trinken", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			post := &models.Post{Title: "Wasser trinken", HeroImage: &models.ImageRef{Alt: tt.alt}}
+			attachment := &models.Attachment{Title: tt.title, URL: "https://example.com/wasser.jpg"}
+
+			if got := heroAlt(post, attachment); got != tt.want {
+				t.Errorf("heroAlt() = %q, want %q", got, tt.want)
+			}
+			if warned := len(post.Warnings) > 0; warned != tt.wantWarned {
+				t.Errorf("heroAlt() warnings = %v, want warning: %t", post.Warnings, tt.wantWarned)
+			}
+		})
+	}
+}
+
+func TestGetDescriptionFromSEO(t *testing.T) {
+	excerpt := `<p>Mikroplastik steckt in Wasser, Salz &amp;amp; Meeresfrüchten. Wir zeigen, wie es in den Körper gelangt, welche Risiken Studien beschreiben und wie du deine Belastung im Alltag ohne großen Aufwand deutlich senken kannst.</p>`
+	post := &models.Post{
+		Excerpt: excerpt,
+		SEO:     models.SEOMeta{Description: excerpt},
+	}
+
+	got := (&Generator{}).getDescription(post)
+	if strings.ContainsAny(got, "<>") || strings.Contains(got, "&amp;") {
+		t.Errorf("getDescription() = %q, want plain text", got)
+	}
+	if n := utf8.RuneCountInString(got); n > descriptionMaxLength {
+		t.Errorf("getDescription() has %d characters, want at most %d", n, descriptionMaxLength)
+	}
+	if !strings.HasPrefix(got, "Mikroplastik steckt in Wasser, Salz & Meeresfrüchten.") {
+		t.Errorf("getDescription() = %q", got)
+	}
+}
@@ -0,0 +1,336 @@
+// Package glossary loads the site's glossary collection
+// (src/data/glossary/<id>.mdx) and links the first mention of each term in
+// a post to its entry.
+package glossary
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/aequinox/gesundes-leben/wp2mdx/pkg/keywords"
+	"gopkg.in/yaml.v3"
+)
+
+// Component is the MDX component glossary mentions are wrapped in
+const Component = "GlossaryTooltip"
+
+// Term is an entry of the glossary collection
+type Term struct {
+	ID       string
+	Title    string
+	Synonyms []string
+}
+
+// variant is a spelling of a term as a sequence of word stems. Acronyms
+// such as "SAM" match case-sensitively and without stemming.
+type variant struct {
+	term    *Term
+	words   []string
+	acronym bool
+}
+
+// titleSeparator splits titles naming several forms of a term, e.g.
+// "Antioxidans – Antioxidantien" or "Somatropin / Somatotropin"
+var titleSeparator = regexp.MustCompile(`\s+[–—/-]\s+`)
+
+// titleAside matches a parenthesized alternative, e.g. "Niacin (Vitamin B3)"
+var titleAside = regexp.MustCompile(`^(.+?)\s*\(([^)]+)\)$`)
+
+// Glossary is the set of terms that posts are linked to
+type Glossary struct {
+	dir      string
+	terms    []*Term
+	variants []variant
+}
+
+// Load reads the glossary entries in dir. A missing directory yields an
+// empty glossary.
+func Load(dir string) (*Glossary, error) {
+	g := &Glossary{}
+	if dir == "" {
+		return g, nil
+	}
+	if _, err := os.Stat(dir); err != nil {
+		if os.IsNotExist(err) {
+			return g, nil
+		}
+		return nil, fmt.Errorf("failed to read glossary directory: %w", err)
+	}
+	g.dir = dir
+
+	var files []string
+	for _, pattern := range []string{"*.md", "*.mdx"} {
+		matches, err := filepath.Glob(filepath.Join(dir, pattern))
+		if err != nil {
+			return nil, fmt.Errorf("failed to list glossary: %w", err)
+		}
+		files = append(files, matches...)
+	}
+
+	sort.Strings(files)
+	for _, file := range files {
+		// The collection skips files starting with an underscore
+		if strings.HasPrefix(filepath.Base(file), "_") {
+			continue
+		}
+		term, err := readTerm(file)
+		if err != nil {
+			return nil, err
+		}
+		if term.Title != "" {
+			g.add(term)
+		}
+	}
+
+	// Longer spellings win, so "Oxidativer Stress" is not cut short by a
+	// term for "Stress"
+	sort.SliceStable(g.variants, func(i, j int) bool {
+		return len(g.variants[i].words) > len(g.variants[j].words)
+	})
+
+	return g, nil
+}
+
+// readTerm reads the title and synonyms of a glossary file
+func readTerm(filename string) (*Term, error) {
+	term := &Term{ID: strings.TrimSuffix(filepath.Base(filename), filepath.Ext(filename))}
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read glossary term %s: %w", filename, err)
+	}
+
+	data = bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n"))
+	if !bytes.HasPrefix(data, []byte("---\n")) {
+		return term, nil
+	}
+	end := bytes.Index(data[4:], []byte("\n---"))
+	if end == -1 {
+		return term, nil
+	}
+
+	var fm struct {
+		Title    string   `yaml:"title"`
+		Synonyms []string `yaml:"synonyms"`
+	}
+	if err := yaml.Unmarshal(data[4:4+end], &fm); err != nil {
+		return nil, fmt.Errorf("failed to parse glossary term %s: %w", filename, err)
+	}
+	term.Title = strings.TrimSpace(fm.Title)
+	term.Synonyms = fm.Synonyms
+
+	return term, nil
+}
+
+// add indexes the spellings of a term: the title, its parts and synonyms
+func (g *Glossary) add(term *Term) {
+	g.terms = append(g.terms, term)
+
+	seen := make(map[string]bool)
+	for _, spelling := range spellings(term) {
+		v := newVariant(term, spelling)
+		key := strings.Join(v.words, " ")
+		if len(v.words) == 0 || seen[key] {
+			continue
+		}
+		seen[key] = true
+		g.variants = append(g.variants, v)
+	}
+}
+
+// spellings returns the forms a term may appear in
+func spellings(term *Term) []string {
+	var forms []string
+	for _, part := range titleSeparator.Split(term.Title, -1) {
+		if m := titleAside.FindStringSubmatch(part); m != nil {
+			forms = append(forms, m[1], m[2])
+		} else {
+			forms = append(forms, part)
+		}
+	}
+	forms = append(forms, term.Title)
+	return append(forms, term.Synonyms...)
+}
+
+// newVariant stems the words of a spelling
+func newVariant(term *Term, spelling string) variant {
+	v := variant{term: term, acronym: isAcronym(spelling)}
+	for _, w := range words(spelling) {
+		v.words = append(v.words, v.key(spelling[w.start:w.end]))
+	}
+	return v
+}
+
+// key returns the form a word of the text is compared in
+func (v variant) key(word string) string {
+	if v.acronym {
+		return word
+	}
+	return stem(word)
+}
+
+// Dir returns the glossary directory, or "" if it does not exist
+func (g *Glossary) Dir() string {
+	return g.dir
+}
+
+// Len returns the number of terms
+func (g *Glossary) Len() int {
+	return len(g.terms)
+}
+
+// Linker links the first mention of each term in one post
+type Linker struct {
+	glossary *Glossary
+	linked   []*Term
+	seen     map[string]bool
+}
+
+// NewLinker creates a linker for one post
+func (g *Glossary) NewLinker() *Linker {
+	return &Linker{glossary: g, seen: make(map[string]bool)}
+}
+
+// Link wraps the first mention of each term not yet linked in the post in
+// the glossary component. text is a run of prose without Markdown syntax.
+// Terms match whole words in any inflection; hyphenated compounds such as
+// "Mikrobiom-Forschung" are words of their own.
+func (l *Linker) Link(text string) string {
+	ws := words(text)
+	if len(ws) == 0 {
+		return text
+	}
+	stems := make([]string, len(ws))
+	for i, w := range ws {
+		stems[i] = stem(text[w.start:w.end])
+	}
+
+	var sb strings.Builder
+	last := 0
+	for i := 0; i < len(ws); i++ {
+		v := l.match(text, ws, stems, i)
+		if v == nil {
+			continue
+		}
+		end := ws[i+len(v.words)-1].end
+		l.seen[v.term.ID] = true
+		l.linked = append(l.linked, v.term)
+
+		sb.WriteString(text[last:ws[i].start])
+		fmt.Fprintf(&sb, "<%s termId=\"%s\">%s</%s>", Component, v.term.ID, text[ws[i].start:end], Component)
+		last = end
+		i += len(v.words) - 1
+	}
+	if last == 0 {
+		return text
+	}
+	sb.WriteString(text[last:])
+	return sb.String()
+}
+
+// match returns the longest spelling of an unlinked term starting at word
+// i, or nil
+func (l *Linker) match(text string, ws []word, stems []string, i int) *variant {
+	for k := range l.glossary.variants {
+		v := &l.glossary.variants[k]
+		if l.seen[v.term.ID] || i+len(v.words) > len(ws) {
+			continue
+		}
+		ok := true
+		for j, key := range v.words {
+			w := ws[i+j]
+			got := stems[i+j]
+			if v.acronym {
+				got = text[w.start:w.end]
+			}
+			// Words of a phrase are separated by spaces only
+			if got != key || j > 0 && strings.Trim(text[ws[i+j-1].end:w.start], " \u00a0") != "" {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			return v
+		}
+	}
+	return nil
+}
+
+// Linked returns the terms linked so far, in the order they appear
+func (l *Linker) Linked() []Term {
+	terms := make([]Term, len(l.linked))
+	for i, t := range l.linked {
+		terms[i] = *t
+	}
+	return terms
+}
+
+// word is the byte range of a word in a text
+type word struct {
+	start, end int
+}
+
+// words splits text into words of letters, digits and inner hyphens
+func words(text string) []word {
+	var ws []word
+	start := -1
+	for i, r := range text {
+		inWord := unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' && start != -1
+		switch {
+		case inWord && start == -1:
+			start = i
+		case !inWord && start != -1:
+			ws = append(ws, trimHyphens(text, start, i))
+			start = -1
+		}
+	}
+	if start != -1 {
+		ws = append(ws, trimHyphens(text, start, len(text)))
+	}
+	return ws
+}
+
+// trimHyphens drops trailing hyphens of a word, as in "Vitamin- und"
+func trimHyphens(text string, start, end int) word {
+	for end > start && text[end-1] == '-' {
+		end--
+	}
+	return word{start, end}
+}
+
+// inflections are endings stripped after stemming. The stemmer leaves
+// short words such as "freie" and "freien" alone; stripping one more ending
+// gives both the stem "frei".
+var inflections = []string{"en", "em", "er", "es", "e", "n", "s"}
+
+// stem returns the lowercase stem of a word
+func stem(w string) string {
+	s := keywords.Stem(strings.ToLower(w))
+	for _, ending := range inflections {
+		if strings.HasSuffix(s, ending) && utf8.RuneCountInString(s)-len(ending) >= 4 {
+			return strings.TrimSuffix(s, ending)
+		}
+	}
+	return s
+}
+
+// isAcronym reports whether a spelling is an all-caps abbreviation
+func isAcronym(s string) bool {
+	letters := 0
+	for _, r := range s {
+		if unicode.IsLower(r) {
+			return false
+		}
+		if unicode.IsLetter(r) {
+			letters++
+		}
+	}
+	return letters >= 2
+}
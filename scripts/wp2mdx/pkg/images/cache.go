@@ -0,0 +1,269 @@
+package images
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Cache is a content-addressed store for downloaded images. Each URL is
+// fetched once; images are stored by the SHA-256 of their original bytes, so
+// identical images under different URLs share one processed file.
+type Cache struct {
+	dir       string
+	processed string
+
+	mu      sync.Mutex
+	urls    map[string]*cacheEntry
+	index   map[string]cachedURL
+	objects map[string]*cacheObject
+}
+
+// cachedURL is a persisted URL → content mapping
+type cachedURL struct {
+	Hash string `json:"hash"`
+	Ext  string `json:"ext"`
+}
+
+// cacheEntry tracks the fetch of a single URL; done is closed once the
+// object (or err) is available
+type cacheEntry struct {
+	done   chan struct{}
+	object *cacheObject
+	err    error
+}
+
+// cacheObject is a processed image in the store. Hash identifies the
+// original bytes; ProcessedHash the file that is placed in post bundles.
+type cacheObject struct {
+	Hash          string
+	ProcessedHash string
+	Path          string
+}
+
+// indexFile records which URLs have been fetched before
+const indexFile = "urls.json"
+
+// NewCache opens the image cache in dir, creating it if necessary. The
+// processed variants are kept per quality and width setting.
+func NewCache(dir string, maxWidth, quality int) (*Cache, error) {
+	c := &Cache{
+		dir:       dir,
+		processed: filepath.Join(dir, "processed", fmt.Sprintf("w%d-q%d", maxWidth, quality)),
+		urls:      make(map[string]*cacheEntry),
+		index:     make(map[string]cachedURL),
+		objects:   make(map[string]*cacheObject),
+	}
+
+	for _, d := range []string{filepath.Join(dir, "objects"), c.processed} {
+		if err := os.MkdirAll(d, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create image cache: %w", err)
+		}
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, indexFile))
+	if err == nil {
+		if err := json.Unmarshal(data, &c.index); err != nil {
+			return nil, fmt.Errorf("failed to parse image cache index: %w", err)
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read image cache index: %w", err)
+	}
+
+	return c, nil
+}
+
+// DefaultCacheDir returns the per-user image cache directory
+func DefaultCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "wp2mdx", "images")
+}
+
+// Get returns the processed image for url. Concurrent calls for the same
+// URL wait for a single fetch. URLs fetched in an earlier run are served
+// from disk unless refresh is set. fetched reports whether fetch was called.
+func (c *Cache) Get(url, ext string, refresh bool,
+	fetch func() ([]byte, error),
+	process func(data []byte) ([]byte, error)) (object *cacheObject, fetched bool, err error) {
+
+	c.mu.Lock()
+	if entry, ok := c.urls[url]; ok {
+		c.mu.Unlock()
+		<-entry.done
+		return entry.object, false, entry.err
+	}
+	entry := &cacheEntry{done: make(chan struct{})}
+	c.urls[url] = entry
+	known, ok := c.index[url]
+	c.mu.Unlock()
+
+	defer close(entry.done)
+
+	if ok && !refresh {
+		if object, err := c.object(known.Hash, known.Ext, nil, process); err == nil {
+			entry.object = object
+			return object, false, nil
+		}
+		// Fall through and fetch again if the store lost the object
+	}
+
+	data, err := fetch()
+	if err != nil {
+		entry.err = err
+		return nil, true, err
+	}
+
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+
+	entry.object, entry.err = c.object(hash, ext, data, process)
+
+	if entry.err == nil {
+		c.mu.Lock()
+		c.index[url] = cachedURL{Hash: hash, Ext: ext}
+		c.mu.Unlock()
+	}
+
+	return entry.object, true, entry.err
+}
+
+// object returns the processed object for hash, storing the original and
+// processing it on first use. data may be nil if the original is already
+// in the store.
+func (c *Cache) object(hash, ext string, data []byte, process func([]byte) ([]byte, error)) (*cacheObject, error) {
+	c.mu.Lock()
+	object, ok := c.objects[hash]
+	c.mu.Unlock()
+	if ok {
+		return object, nil
+	}
+
+	original := filepath.Join(c.dir, "objects", hash+ext)
+	processed := filepath.Join(c.processed, hash+ext)
+
+	processedHash, err := fileHash(processed)
+	if err != nil {
+		if data == nil {
+			if data, err = os.ReadFile(original); err != nil {
+				return nil, err
+			}
+		} else if err := writeFileAtomic(original, data); err != nil {
+			return nil, fmt.Errorf("failed to store image: %w", err)
+		}
+
+		result, err := process(data)
+		if err != nil {
+			return nil, err
+		}
+		if err := writeFileAtomic(processed, result); err != nil {
+			return nil, fmt.Errorf("failed to store processed image: %w", err)
+		}
+
+		sum := sha256.Sum256(result)
+		processedHash = hex.EncodeToString(sum[:])
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Another URL with the same content may have been stored meanwhile
+	if existing, ok := c.objects[hash]; ok {
+		return existing, nil
+	}
+	object = &cacheObject{Hash: hash, ProcessedHash: processedHash, Path: processed}
+	c.objects[hash] = object
+
+	return object, nil
+}
+
+// Save persists the URL index so later runs can skip downloads
+func (c *Cache) Save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	data, err := json.MarshalIndent(c.index, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode image cache index: %w", err)
+	}
+
+	if err := writeFileAtomic(filepath.Join(c.dir, indexFile), data); err != nil {
+		return fmt.Errorf("failed to write image cache index: %w", err)
+	}
+
+	return nil
+}
+
+// writeFileAtomic writes data to a temporary file and renames it into place,
+// so interrupted runs never leave truncated files in the cache
+func writeFileAtomic(filename string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(filename), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0644); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), filename)
+}
+
+// linkOrCopy places a cached file at dest, preferring a hard link and
+// falling back to a copy across file systems
+func linkOrCopy(src, dest string) error {
+	os.Remove(dest)
+	if err := os.Link(src, dest); err == nil {
+		return nil
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+
+	return out.Close()
+}
+
+// fileHash returns the SHA-256 of a file's contents
+func fileHash(filename string) (string, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -13,33 +13,105 @@ import (
 	"github.com/aequinox/gesundes-leben/wp2mdx/pkg/config"
 	"github.com/aequinox/gesundes-leben/wp2mdx/pkg/converter"
 	"github.com/aequinox/gesundes-leben/wp2mdx/pkg/models"
+	"github.com/aequinox/gesundes-leben/wp2mdx/pkg/parser"
 )
 
 // Downloader handles image downloads
 type Downloader struct {
-	config     *config.Config
-	httpClient *http.Client
-	stats      DownloadStats
-	mu         sync.Mutex
+	config      *config.Config
+	attachments *parser.AttachmentIndex
+	cache       *Cache
+	httpClient  *http.Client
+	stats       DownloadStats
+	mu          sync.Mutex
 }
 
 // DownloadStats tracks download statistics
 type DownloadStats struct {
 	Downloaded int
+	Reused     int
 	Failed     int
 	Skipped    int
 	TotalBytes int64
+	BytesSaved int64
 }
 
-// New creates a new image downloader
-func New(cfg *config.Config) *Downloader {
-	return &Downloader{
-		config: cfg,
+// New creates a new image downloader. The attachment index links content
+// images back to their WordPress attachments and may be nil. Downloads go
+// through the shared image cache in cfg.ImageCacheDir.
+func New(cfg *config.Config, attachments *parser.AttachmentIndex) (*Downloader, error) {
+	d := &Downloader{
+		config:      cfg,
+		attachments: attachments,
 		httpClient: &http.Client{
 			Timeout: cfg.Timeout,
 		},
 		stats: DownloadStats{},
 	}
+
+	if cfg.DownloadImages {
+		dir := cfg.ImageCacheDir
+		if dir == "" {
+			dir = DefaultCacheDir()
+		}
+		cache, err := NewCache(dir, cfg.MaxImageWidth, cfg.ImageQuality)
+		if err != nil {
+			return nil, err
+		}
+		d.cache = cache
+	}
+
+	return d, nil
+}
+
+// Close persists the image cache index
+func (d *Downloader) Close() error {
+	if d.cache == nil {
+		return nil
+	}
+	return d.cache.Save()
+}
+
+// bundle tracks the images placed in one post's images folder, so that
+// identical images share a file and different images never overwrite each
+// other
+type bundle struct {
+	dir       string
+	files     map[string]string           // filename → processed hash
+	variables map[string]string           // import variable → processed hash
+	placed    map[string]*models.ImageRef // processed hash → placed image
+	variants  map[string]*models.ImageRef // variant key → placed image
+}
+
+func newBundle(dir string) *bundle {
+	return &bundle{
+		dir:       dir,
+		files:     make(map[string]string),
+		variables: make(map[string]string),
+		placed:    make(map[string]*models.ImageRef),
+		variants:  make(map[string]*models.ImageRef),
+	}
+}
+
+// name returns the filename for an image in the bundle. A name already taken
+// by a different image gets the first 8 hex digits of the content hash
+// appended, which keeps names stable across runs.
+func (b *bundle) name(filename, hash string) string {
+	if owner, ok := b.files[filename]; !ok || owner == hash {
+		return filename
+	}
+	ext := filepath.Ext(filename)
+	return strings.TrimSuffix(filename, ext) + "-" + hash[:8] + ext
+}
+
+// variable returns the import variable for a placed file, disambiguating
+// names that collapse to the same identifier
+func (b *bundle) variable(filename, hash string) string {
+	variable := converter.ImageURLToVariable(filename)
+	if owner, ok := b.variables[variable]; ok && owner != hash {
+		variable += hash[:8]
+	}
+	return variable
 }
 
 // ProcessPost processes all images for a post
@@ -53,28 +125,91 @@ func (d *Downloader) ProcessPost(post *models.Post, outputDir string) error {
 	if err := os.MkdirAll(imagesDir, 0755); err != nil {
 		return fmt.Errorf("failed to create images directory: %w", err)
 	}
+	b := newBundle(imagesDir)
 
 	// Process hero image
 	if post.HeroImage != nil && d.config.DownloadAttached {
-		if err := d.downloadImage(post.HeroImage, imagesDir); err != nil {
+		sources := sourceCandidates(post.HeroImage.URL, "", nil)
+		if err := d.downloadImage(post.HeroImage, sources, b); err != nil {
 			// Log error but continue
 			d.recordFailure()
+		} else {
+			b.variants[parser.UploadKey(post.HeroImage.URL)] = post.HeroImage
+		}
+	}
+
+	// Process the social sharing image, unless it is a variant of the hero
+	if post.OGImage != nil && d.config.DownloadAttached {
+		if placed, ok := b.variants[parser.UploadKey(post.OGImage.URL)]; ok {
+			post.OGImage.LocalPath = placed.LocalPath
+			post.OGImage.Variable = placed.Variable
+			post.OGImage.Downloaded = true
+		} else if err := d.downloadImage(post.OGImage, sourceCandidates(post.OGImage.URL, "", nil), b); err != nil {
+			d.recordFailure()
+		} else {
+			b.variants[parser.UploadKey(post.OGImage.URL)] = post.OGImage
 		}
 	}
 
 	// Process content images
 	if d.config.DownloadScraped {
 		contentImages := converter.ExtractImages(post.Content)
+
+		// Galleries reference their images by attachment ID only
+		seen := make(map[string]bool)
+		for _, img := range contentImages {
+			seen[img.URL] = true
+		}
+		for _, id := range converter.GalleryAttachmentIDs(post.Content) {
+			if attachment := d.attachments.ByID(id); attachment != nil && !seen[attachment.URL] {
+				seen[attachment.URL] = true
+				contentImages = append(contentImages, converter.ImageInfo{
+					URL:          attachment.URL,
+					Alt:          attachment.Alt,
+					Position:     "center",
+					AttachmentID: attachment.ID,
+				})
+			}
+		}
+
 		for _, img := range contentImages {
 			imgRef := &models.ImageRef{
 				URL:      img.URL,
 				Alt:      img.Alt,
 				Position: img.Position,
 			}
-			if err := d.downloadImage(imgRef, imagesDir); err != nil {
+
+			attachment := d.attachments.ByURL(img.URL)
+			if attachment == nil && img.AttachmentID != 0 {
+				attachment = d.attachments.ByID(img.AttachmentID)
+			}
+			attachmentURL := ""
+			if attachment != nil {
+				imgRef.ApplyAttachment(attachment)
+				attachmentURL = attachment.URL
+			}
+
+			// All size variants of an upload, including the featured image
+			// embedded again in the content, share one file and variable
+			key := parser.UploadKey(img.URL)
+			placed, ok := b.variants[key]
+			if !ok && attachmentURL != "" {
+				placed, ok = b.variants[parser.UploadKey(attachmentURL)]
+			}
+			if ok {
+				imgRef.LocalPath = placed.LocalPath
+				imgRef.Variable = placed.Variable
+				imgRef.Downloaded = true
+				post.Images = append(post.Images, *imgRef)
+				continue
+			}
+
+			sources := sourceCandidates(img.URL, attachmentURL, img.Srcset)
+			if err := d.downloadImage(imgRef, sources, b); err != nil {
 				// Log error but continue
 				d.recordFailure()
 			} else {
+				b.variants[key] = imgRef
 				post.Images = append(post.Images, *imgRef)
 			}
 		}
@@ -83,81 +218,127 @@ func (d *Downloader) ProcessPost(post *models.Post, outputDir string) error {
 	return nil
 }
 
-// downloadImage downloads a single image
-func (d *Downloader) downloadImage(img *models.ImageRef, outputDir string) error {
+// absoluteURL resolves protocol-relative and relative image URLs
+func (d *Downloader) absoluteURL(url string) (string, error) {
+	if strings.HasPrefix(url, "//") {
+		return "https:" + url, nil
+	}
+	if strings.HasPrefix(url, "http") {
+		return url, nil
+	}
+	if d.config.ImageBaseURL != "" {
+		return d.config.ImageBaseURL + url, nil
+	}
+	return "", fmt.Errorf("relative URL without base URL: %s", url)
+}
+
+// downloadImage fetches an image through the cache from the first source
+// that succeeds and places it in the post bundle
+func (d *Downloader) downloadImage(img *models.ImageRef, sources []string, b *bundle) error {
 	if img.URL == "" {
 		return fmt.Errorf("empty image URL")
 	}
 
-	// Normalize URL
-	url := img.URL
-	if strings.HasPrefix(url, "//") {
-		url = "https:" + url
-	} else if !strings.HasPrefix(url, "http") {
-		if d.config.ImageBaseURL != "" {
-			url = d.config.ImageBaseURL + url
+	var object *cacheObject
+	var filename string
+	var errs []string
+
+	for _, source := range sources {
+		url, err := d.absoluteURL(source)
+		if err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+
+		// Extract filename
+		name := extractFilename(url)
+		if name == "" {
+			name = fmt.Sprintf("image-%s.jpg", time.Now().Format("20060102-150405"))
+		}
+
+		result, fetched, err := d.cache.Get(url, strings.ToLower(filepath.Ext(name)), d.config.Force,
+			func() ([]byte, error) {
+				return d.download(url)
+			},
+			func(data []byte) ([]byte, error) {
+				processed, err := processImage(data, name, d.config.MaxImageWidth, d.config.ImageQuality)
+				if err == nil {
+					d.recordBytes(0, int64(len(data)-len(processed)))
+				}
+				return processed, err
+			})
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", url, err))
+			continue
+		}
+
+		if fetched {
+			d.recordSuccess()
 		} else {
-			return fmt.Errorf("relative URL without base URL: %s", url)
+			d.recordReuse()
 		}
+		object, filename = result, name
+		break
+	}
+
+	if object == nil {
+		return fmt.Errorf("failed to download %s: %s", img.URL, strings.Join(errs, "; "))
 	}
 
-	// Extract filename
-	filename := extractFilename(url)
-	if filename == "" {
-		filename = fmt.Sprintf("image-%s.jpg", time.Now().Format("20060102-150405"))
+	// Byte-identical images in the same post share one file
+	if placed, ok := b.placed[object.ProcessedHash]; ok {
+		img.LocalPath = placed.LocalPath
+		img.Variable = placed.Variable
+		img.Downloaded = true
+		return nil
 	}
 
 	// Set local path
-	localPath := filepath.Join(outputDir, filename)
+	filename = b.name(filename, object.ProcessedHash)
+	localPath := filepath.Join(b.dir, filename)
 	img.LocalPath = "./images/" + filename
-	img.Variable = converter.ImageURLToVariable(filename)
+	img.Variable = b.variable(filename, object.ProcessedHash)
 
-	// Skip if already exists and not forcing
-	if _, err := os.Stat(localPath); err == nil && !d.config.Force {
+	b.files[filename] = object.ProcessedHash
+	b.variables[img.Variable] = object.ProcessedHash
+	b.placed[object.ProcessedHash] = img
+
+	// Keep existing files unless forcing; identical files need no update
+	if hash, err := fileHash(localPath); err == nil && (hash == object.ProcessedHash || !d.config.Force) {
 		d.recordSkip()
 		img.Downloaded = true
 		return nil
 	}
 
-	// Download the image
-	if err := d.download(url, localPath); err != nil {
-		return fmt.Errorf("failed to download %s: %w", url, err)
+	if err := linkOrCopy(object.Path, localPath); err != nil {
+		return fmt.Errorf("failed to place %s: %w", filename, err)
 	}
 
 	img.Downloaded = true
-	d.recordSuccess()
 
 	return nil
 }
 
 // download performs the actual HTTP download
-func (d *Downloader) download(url, dest string) error {
+func (d *Downloader) download(url string) ([]byte, error) {
 	resp, err := d.httpClient.Get(url)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, resp.Status)
+		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, resp.Status)
 	}
 
-	// Create output file
-	out, err := os.Create(dest)
+	data, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	defer out.Close()
 
-	// Copy data
-	written, err := io.Copy(out, resp.Body)
-	if err != nil {
-		return err
-	}
-
-	d.recordBytes(written)
+	d.recordBytes(int64(len(data)), 0)
 
-	return nil
+	return data, nil
 }
 
 // extractFilename extracts filename from URL
@@ -199,6 +380,13 @@ func (d *Downloader) recordSuccess() {
 	d.stats.Downloaded++
 }
 
+// recordReuse records an image served from the cache
+func (d *Downloader) recordReuse() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.stats.Reused++
+}
+
 // recordFailure records a failed download
 func (d *Downloader) recordFailure() {
 	d.mu.Lock()
@@ -213,11 +401,12 @@ func (d *Downloader) recordSkip() {
 	d.stats.Skipped++
 }
 
-// recordBytes records downloaded bytes
-func (d *Downloader) recordBytes(bytes int64) {
+// recordBytes records downloaded bytes and the bytes saved by processing
+func (d *Downloader) recordBytes(bytes, saved int64) {
 	d.mu.Lock()
 	defer d.mu.Unlock()
 	d.stats.TotalBytes += bytes
+	d.stats.BytesSaved += saved
 }
 
 // GetStats returns download statistics
@@ -227,13 +416,11 @@ func (d *Downloader) GetStats() DownloadStats {
 	return d.stats
 }
 
-// GenerateImports generates import statements for images
+// GenerateImports generates import statements for downloaded images.
+// Component imports are derived from the converted content by the writer.
 func GenerateImports(post *models.Post) string {
 	var imports []string
 
-	// Always import Image component
-	imports = append(imports, `import Image from "@/components/elements/Image.astro";`)
-
 	// Add hero image import
 	if post.HeroImage != nil && post.HeroImage.Downloaded {
 		imports = append(imports, fmt.Sprintf("import %s from \"%s\";",
@@ -242,6 +429,9 @@ func GenerateImports(post *models.Post) string {
 
 	// Add content image imports
 	seen := make(map[string]bool)
+	if post.HeroImage != nil && post.HeroImage.Downloaded {
+		seen[post.HeroImage.Variable] = true
+	}
 	for _, img := range post.Images {
 		if img.Downloaded && !seen[img.Variable] {
 			imports = append(imports, fmt.Sprintf("import %s from \"%s\";",
@@ -250,9 +440,5 @@ func GenerateImports(post *models.Post) string {
 		}
 	}
 
-	if len(imports) <= 1 {
-		return ""
-	}
-
 	return strings.Join(imports, "\n")
 }
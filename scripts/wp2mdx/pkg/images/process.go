@@ -0,0 +1,80 @@
+package images
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"path/filepath"
+	"strings"
+)
+
+// processImage downscales images wider than maxWidth and re-encodes JPEGs at
+// quality. Formats that can't be processed (SVG, WebP, animated GIF) and
+// images that would not get smaller are returned unchanged.
+func processImage(data []byte, filename string, maxWidth, quality int) ([]byte, error) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".svg", ".webp":
+		return data, nil
+	}
+
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		// Not an image we can decode; keep the original
+		return data, nil
+	}
+
+	needsResize := maxWidth > 0 && config.Width > maxWidth
+	if !needsResize && format != "jpeg" {
+		// Only JPEG benefits from re-encoding at the configured quality
+		return data, nil
+	}
+
+	if format == "gif" {
+		animation, err := gif.DecodeAll(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode gif: %w", err)
+		}
+		if len(animation.Image) > 1 {
+			return data, nil
+		}
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", format, err)
+	}
+
+	if needsResize {
+		height := config.Height * maxWidth / config.Width
+		if height < 1 {
+			height = 1
+		}
+		img = resize(img, maxWidth, height)
+	}
+
+	var buf bytes.Buffer
+	switch format {
+	case "jpeg":
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality})
+	case "png":
+		encoder := png.Encoder{CompressionLevel: png.BestCompression}
+		err = encoder.Encode(&buf, img)
+	case "gif":
+		err = gif.Encode(&buf, img, &gif.Options{NumColors: 256})
+	default:
+		return data, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s: %w", format, err)
+	}
+
+	// Re-encoding an already well-compressed image can make it larger
+	if !needsResize && buf.Len() >= len(data) {
+		return data, nil
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,149 @@
+package images
+
+import (
+	"image"
+	"image/draw"
+	"math"
+)
+
+// lanczosSupport is the radius of the Lanczos-3 kernel
+const lanczosSupport = 3.0
+
+// lanczos evaluates the Lanczos-3 kernel at x
+func lanczos(x float64) float64 {
+	x = math.Abs(x)
+	if x < 1e-8 {
+		return 1
+	}
+	if x >= lanczosSupport {
+		return 0
+	}
+	px := math.Pi * x
+	return lanczosSupport * math.Sin(px) * math.Sin(px/lanczosSupport) / (px * px)
+}
+
+// filterWeights holds the source indices and normalized weights that make up
+// one output sample
+type filterWeights struct {
+	start   int
+	weights []float64
+}
+
+// computeWeights precomputes the Lanczos weights for resampling srcSize
+// samples to dstSize samples. When downscaling the kernel is stretched to
+// cover all contributing source samples.
+func computeWeights(srcSize, dstSize int) []filterWeights {
+	scale := float64(srcSize) / float64(dstSize)
+	filterScale := math.Max(scale, 1)
+	support := lanczosSupport * filterScale
+
+	result := make([]filterWeights, dstSize)
+	for i := range result {
+		center := (float64(i)+0.5)*scale - 0.5
+		start := int(math.Floor(center-support)) + 1
+		end := int(math.Floor(center + support))
+
+		weights := make([]float64, 0, end-start+1)
+		sum := 0.0
+		for j := start; j <= end; j++ {
+			w := lanczos((float64(j) - center) / filterScale)
+			weights = append(weights, w)
+			sum += w
+		}
+		if sum != 0 {
+			for j := range weights {
+				weights[j] /= sum
+			}
+		}
+
+		result[i] = filterWeights{start: start, weights: weights}
+	}
+
+	return result
+}
+
+// resize scales src to width x height with a separable Lanczos-3 filter.
+// Colors are resampled premultiplied so transparent edges don't darken.
+func resize(src image.Image, width, height int) *image.RGBA {
+	bounds := src.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), src, bounds.Min, draw.Src)
+
+	horizontal := resampleAxis(rgba, width, rgba.Bounds().Dy(), true)
+	return resampleAxis(horizontal, width, height, false)
+}
+
+// resampleAxis resamples src along one axis into a width x height image
+func resampleAxis(src *image.RGBA, width, height int, horizontal bool) *image.RGBA {
+	srcW, srcH := src.Bounds().Dx(), src.Bounds().Dy()
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	srcSize, dstSize := srcH, height
+	if horizontal {
+		srcSize, dstSize = srcW, width
+	}
+	filters := computeWeights(srcSize, dstSize)
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			var f filterWeights
+			if horizontal {
+				f = filters[x]
+			} else {
+				f = filters[y]
+			}
+
+			var r, g, b, a float64
+			for k, w := range f.weights {
+				j := clampInt(f.start+k, 0, srcSize-1)
+				offset := src.PixOffset(x, j)
+				if horizontal {
+					offset = src.PixOffset(j, y)
+				}
+				r += w * float64(src.Pix[offset])
+				g += w * float64(src.Pix[offset+1])
+				b += w * float64(src.Pix[offset+2])
+				a += w * float64(src.Pix[offset+3])
+			}
+
+			alpha := clampChannel(a)
+			offset := dst.PixOffset(x, y)
+			dst.Pix[offset] = minByte(clampChannel(r), alpha)
+			dst.Pix[offset+1] = minByte(clampChannel(g), alpha)
+			dst.Pix[offset+2] = minByte(clampChannel(b), alpha)
+			dst.Pix[offset+3] = alpha
+		}
+	}
+
+	return dst
+}
+
+func clampInt(v, lo, hi int) int {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
+
+// clampChannel rounds a filtered value to a color channel; Lanczos lobes
+// can overshoot the valid range
+func clampChannel(v float64) uint8 {
+	v = math.Round(v)
+	if v < 0 {
+		return 0
+	}
+	if v > 255 {
+		return 255
+	}
+	return uint8(v)
+}
+
+func minByte(a, b uint8) uint8 {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,59 @@
+package images
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+func TestProcessImagePortrait(t *testing.T) {
+	// Both sides exceed the maximum width, and the image is taller than wide
+	const maxWidth = 120
+	src := image.NewRGBA(image.Rect(0, 0, 240, 400))
+	for y := 0; y < 400; y++ {
+		for x := 0; x < 240; x++ {
+			src.Set(x, y, color.RGBA{uint8(x), uint8(y), 128, 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, src); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := processImage(buf.Bytes(), "portrait.png", maxWidth, 80)
+	if err != nil {
+		t.Fatalf("processImage() error = %v", err)
+	}
+
+	config, err := png.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("failed to decode result: %v", err)
+	}
+	if config.Width != maxWidth || config.Height != 200 {
+		t.Errorf("processImage() size = %dx%d, want %dx200", config.Width, config.Height, maxWidth)
+	}
+}
+
+func TestResizeKeepsSolidColor(t *testing.T) {
+	fill := color.RGBA{200, 100, 50, 255}
+	src := image.NewRGBA(image.Rect(0, 0, 30, 90))
+	for y := 0; y < 90; y++ {
+		for x := 0; x < 30; x++ {
+			src.SetRGBA(x, y, fill)
+		}
+	}
+
+	dst := resize(src, 10, 30)
+	if got := dst.Bounds().Size(); got != image.Pt(10, 30) {
+		t.Fatalf("resize() size = %v, want (10,30)", got)
+	}
+	for y := 0; y < 30; y++ {
+		for x := 0; x < 10; x++ {
+			if got := dst.RGBAAt(x, y); got != fill {
+				t.Fatalf("resize() pixel (%d,%d) = %v, want %v", x, y, got, fill)
+			}
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package images
+
+import (
+	"path"
+	"regexp"
+	"strings"
+)
+
+// WordPress names generated files after the upload: "photo-300x200.jpg" for
+// intermediate sizes, "photo-scaled.jpg" for the big-image threshold copy and
+// "photo-rotated.jpg" for EXIF-rotated copies.
+var (
+	sizeSuffixRe   = regexp.MustCompile(`-\d+x\d+$`)
+	scaledSuffixRe = regexp.MustCompile(`-scaled$`)
+)
+
+// splitURL separates a URL into the part before the extension and the
+// extension, dropping query and fragment
+func splitURL(url string) (base, ext string) {
+	if idx := strings.IndexAny(url, "?#"); idx != -1 {
+		url = url[:idx]
+	}
+	ext = path.Ext(url)
+	return strings.TrimSuffix(url, ext), ext
+}
+
+// OriginalURL returns the URL of the upload a size variant was generated
+// from. Rotated copies are kept, since the unrotated original depends on
+// EXIF orientation that re-encoding discards.
+func OriginalURL(url string) string {
+	base, ext := splitURL(url)
+	base = sizeSuffixRe.ReplaceAllString(base, "")
+	base = scaledSuffixRe.ReplaceAllString(base, "")
+	return base + ext
+}
+
+// sourceCandidates lists the URLs an image can be downloaded from, best
+// first: the original upload, the attachment file, the largest srcset
+// candidate and finally the referenced URL itself
+func sourceCandidates(url, attachmentURL string, srcset []string) []string {
+	var candidates []string
+	seen := make(map[string]bool)
+
+	add := func(u string) {
+		if u != "" && !seen[u] {
+			seen[u] = true
+			candidates = append(candidates, u)
+		}
+	}
+
+	add(OriginalURL(url))
+	if attachmentURL != "" {
+		add(OriginalURL(attachmentURL))
+		add(attachmentURL)
+	}
+	if len(srcset) > 0 {
+		add(srcset[0])
+	}
+	add(url)
+
+	return candidates
+}
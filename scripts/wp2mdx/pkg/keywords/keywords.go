@@ -0,0 +1,239 @@
+// Package keywords extracts keywords from German blog posts. Terms are
+// stemmed, weighted by where they occur in a post and scored by TF-IDF
+// against the whole export, so that words every post uses rank low.
+package keywords
+
+import (
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// Weights of a term occurrence by where it appears in a post
+const (
+	bodyWeight    = 1.0
+	headingWeight = 3.0
+	titleWeight   = 4.0
+	tagWeight     = 4.0
+)
+
+// minTermLength is the minimum length of a term in runes
+const minTermLength = 3
+
+// shortcodeRe matches WordPress shortcode tags, which are not prose
+var shortcodeRe = regexp.MustCompile(`\[/?[a-z][a-z0-9_-]*(?:\s[^\]]*)?/?\]`)
+
+// inlineElements don't separate words, unlike block elements
+var inlineElements = map[string]bool{
+	"a": true, "abbr": true, "b": true, "em": true, "i": true, "mark": true,
+	"s": true, "small": true, "span": true, "strong": true, "sub": true,
+	"sup": true, "u": true,
+}
+
+// skippedElements contain no prose
+var skippedElements = map[string]bool{
+	"code": true, "pre": true, "script": true, "style": true, "svg": true,
+}
+
+// Document holds the weighted terms of one post
+type Document struct {
+	terms map[string]float64        // stem → weighted count
+	forms map[string]map[string]int // stem → spelling → count
+	total float64
+}
+
+// NewDocument tokenizes a post. content is the post HTML; markup,
+// shortcodes and code are stripped before counting terms.
+func NewDocument(title, content string, tags []string) *Document {
+	d := &Document{
+		terms: make(map[string]float64),
+		forms: make(map[string]map[string]int),
+	}
+
+	d.add(title, titleWeight)
+	for _, tag := range tags {
+		d.add(tag, tagWeight)
+	}
+
+	content = shortcodeRe.ReplaceAllString(content, " ")
+	html, err := goquery.NewDocumentFromReader(strings.NewReader(content))
+	if err != nil {
+		return d
+	}
+
+	html.Find("h1, h2, h3, h4, h5, h6").Each(func(_ int, s *goquery.Selection) {
+		// Headings also count as body text below
+		d.add(text(s), headingWeight-bodyWeight)
+	})
+	d.add(text(html.Selection), bodyWeight)
+
+	return d
+}
+
+// add counts the terms in text with the given weight
+func (d *Document) add(text string, weight float64) {
+	for _, word := range tokenize(text) {
+		lower := strings.ToLower(word)
+		if stopwords[lower] {
+			continue
+		}
+
+		stem := Stem(lower)
+		d.terms[stem] += weight
+		d.total += weight
+
+		if d.forms[stem] == nil {
+			d.forms[stem] = make(map[string]int)
+		}
+		d.forms[stem][word]++
+	}
+}
+
+// form returns the most frequent spelling of a stem, preferring the
+// shorter and then alphabetically first spelling on ties
+func (d *Document) form(stem string) string {
+	best, bestCount := "", 0
+	for form, count := range d.forms[stem] {
+		switch {
+		case count > bestCount,
+			count == bestCount && len(form) < len(best),
+			count == bestCount && len(form) == len(best) && form < best:
+			best, bestCount = form, count
+		}
+	}
+	return best
+}
+
+// Corpus collects document frequencies across an export
+type Corpus struct {
+	documents int
+	frequency map[string]int
+}
+
+// NewCorpus creates an empty corpus
+func NewCorpus() *Corpus {
+	return &Corpus{frequency: make(map[string]int)}
+}
+
+// Add counts the terms of a document
+func (c *Corpus) Add(d *Document) {
+	c.documents++
+	for stem := range d.terms {
+		c.frequency[stem]++
+	}
+}
+
+// idf returns the smoothed inverse document frequency of a stem. Without
+// a corpus all terms weigh the same.
+func (c *Corpus) idf(stem string) float64 {
+	if c == nil || c.documents == 0 {
+		return 1
+	}
+	return math.Log(float64(1+c.documents)/float64(1+c.frequency[stem])) + 1
+}
+
+// Keywords returns up to max nouns of a document as keywords, best first.
+// Ties are broken alphabetically so the result does not change between
+// runs. A nil corpus ranks by term frequency alone.
+func (c *Corpus) Keywords(d *Document, max int) []string {
+	if d.total == 0 {
+		return nil
+	}
+
+	type scored struct {
+		stem  string
+		score float64
+	}
+	terms := make([]scored, 0, len(d.terms))
+	for stem, count := range d.terms {
+		terms = append(terms, scored{stem, count / d.total * c.idf(stem)})
+	}
+
+	sort.Slice(terms, func(i, j int) bool {
+		if terms[i].score != terms[j].score {
+			return terms[i].score > terms[j].score
+		}
+		return terms[i].stem < terms[j].stem
+	})
+
+	var keywords []string
+	seen := make(map[string]bool)
+	for _, term := range terms {
+		if len(keywords) == max {
+			break
+		}
+		form := d.form(term.stem)
+		if first, _ := utf8.DecodeRuneInString(form); !unicode.IsUpper(first) {
+			// German nouns are capitalized; lowercase terms are mostly
+			// adjectives and verbs, which make poor keywords
+			continue
+		}
+		if key := strings.ToLower(form); !seen[key] {
+			seen[key] = true
+			keywords = append(keywords, form)
+		}
+	}
+
+	return keywords
+}
+
+// PlainText returns the prose of post HTML, with markup, shortcodes and code
+// removed and words separated at block boundaries
+func PlainText(content string) string {
+	content = shortcodeRe.ReplaceAllString(content, " ")
+	html, err := goquery.NewDocumentFromReader(strings.NewReader(content))
+	if err != nil {
+		return content
+	}
+	return text(html.Selection)
+}
+
+// text returns the prose of an HTML selection with words separated at
+// block boundaries
+func text(s *goquery.Selection) string {
+	var sb strings.Builder
+	collectText(s, &sb)
+	return sb.String()
+}
+
+// collectText appends the text below s to sb, padding block elements
+func collectText(s *goquery.Selection, sb *strings.Builder) {
+	s.Contents().Each(func(_ int, child *goquery.Selection) {
+		name := goquery.NodeName(child)
+		switch {
+		case name == "#text":
+			sb.WriteString(child.Text())
+		case skippedElements[name]:
+			sb.WriteByte(' ')
+		case inlineElements[name]:
+			collectText(child, sb)
+		default:
+			sb.WriteByte(' ')
+			collectText(child, sb)
+			sb.WriteByte(' ')
+		}
+	})
+}
+
+// tokenize splits text into words, keeping hyphenated compounds such as
+// "Omega-3" together and dropping numbers and short words
+func tokenize(text string) []string {
+	fields := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-'
+	})
+
+	words := fields[:0]
+	for _, word := range fields {
+		word = strings.Trim(word, "-")
+		if utf8.RuneCountInString(word) < minTermLength || strings.IndexFunc(word, unicode.IsLetter) == -1 {
+			continue
+		}
+		words = append(words, word)
+	}
+	return words
+}
@@ -0,0 +1,192 @@
+package keywords
+
+import "strings"
+
+// Stem reduces a lowercase German word to its stem using the Snowball German
+// stemming algorithm, so that inflected forms such as "Vitamine",
+// "Vitaminen" and "Vitamins" share one stem
+func Stem(word string) string {
+	w := []rune(strings.ReplaceAll(word, "ß", "ss"))
+
+	// Mark u and y between vowels as consonants
+	for i := 1; i < len(w)-1; i++ {
+		if isVowel(w[i-1]) && isVowel(w[i+1]) {
+			switch w[i] {
+			case 'u':
+				w[i] = 'U'
+			case 'y':
+				w[i] = 'Y'
+			}
+		}
+	}
+
+	p1, p2 := stemRegions(w)
+
+	w = stemStep1(w, p1)
+	w = stemStep2(w, p1)
+	w = stemStep3(w, p1, p2)
+
+	for i, r := range w {
+		switch r {
+		case 'U':
+			w[i] = 'u'
+		case 'Y':
+			w[i] = 'y'
+		case 'ä':
+			w[i] = 'a'
+		case 'ö':
+			w[i] = 'o'
+		case 'ü':
+			w[i] = 'u'
+		}
+	}
+
+	return string(w)
+}
+
+// isVowel reports whether r is a vowel in the German stemmer's sense
+func isVowel(r rune) bool {
+	switch r {
+	case 'a', 'e', 'i', 'o', 'u', 'y', 'ä', 'ö', 'ü':
+		return true
+	}
+	return false
+}
+
+// stemRegions returns the start of R1 and R2. R1 begins after the first
+// non-vowel following a vowel, but at least at the fourth letter; R2 is
+// the same region computed again from R1.
+func stemRegions(w []rune) (p1, p2 int) {
+	p1, p2 = len(w), len(w)
+	if len(w) < 3 {
+		return p1, p2
+	}
+
+	regionAfter := func(start int) int {
+		for i := start; i < len(w)-1; i++ {
+			if isVowel(w[i]) && !isVowel(w[i+1]) {
+				return i + 2
+			}
+		}
+		return len(w)
+	}
+
+	p1 = regionAfter(0)
+	p2 = regionAfter(p1)
+	if p1 < 3 {
+		p1 = 3
+	}
+	return p1, p2
+}
+
+// longestSuffix returns the longest of suffixes w ends with, or ""
+func longestSuffix(w []rune, suffixes ...string) string {
+	s := string(w)
+	best := ""
+	for _, suffix := range suffixes {
+		if strings.HasSuffix(s, suffix) && len(suffix) > len(best) {
+			best = suffix
+		}
+	}
+	return best
+}
+
+// isSEnding reports whether r may precede an inflectional "s"
+func isSEnding(r rune) bool {
+	return strings.ContainsRune("bdfghklmnrt", r)
+}
+
+// isSTEnding reports whether r may precede an inflectional "st"
+func isSTEnding(r rune) bool {
+	return strings.ContainsRune("bdfghklmnt", r)
+}
+
+// stemStep1 removes inflectional endings: -em -ern -er, -e -en -es and -s
+func stemStep1(w []rune, p1 int) []rune {
+	suffix := longestSuffix(w, "em", "ern", "er", "e", "en", "es", "s")
+	if suffix == "" {
+		return w
+	}
+	start := len(w) - len([]rune(suffix))
+	if start < p1 {
+		return w
+	}
+
+	switch suffix {
+	case "em", "ern", "er":
+		w = w[:start]
+	case "e", "en", "es":
+		w = w[:start]
+		if strings.HasSuffix(string(w), "niss") {
+			w = w[:len(w)-1]
+		}
+	case "s":
+		if start > 0 && isSEnding(w[start-1]) {
+			w = w[:start]
+		}
+	}
+	return w
+}
+
+// stemStep2 removes the endings -en -er -est and -st
+func stemStep2(w []rune, p1 int) []rune {
+	suffix := longestSuffix(w, "en", "er", "est", "st")
+	if suffix == "" {
+		return w
+	}
+	start := len(w) - len([]rune(suffix))
+	if start < p1 {
+		return w
+	}
+
+	switch suffix {
+	case "en", "er", "est":
+		w = w[:start]
+	case "st":
+		if start >= 4 && isSTEnding(w[start-1]) {
+			w = w[:start]
+		}
+	}
+	return w
+}
+
+// stemStep3 removes derivational suffixes such as -ung, -lich, -heit and
+// -keit
+func stemStep3(w []rune, p1, p2 int) []rune {
+	suffix := longestSuffix(w, "end", "ung", "ig", "ik", "isch", "lich", "heit", "keit")
+	if suffix == "" {
+		return w
+	}
+	start := len(w) - len([]rune(suffix))
+	if start < p2 {
+		return w
+	}
+
+	precededByE := start > 0 && w[start-1] == 'e'
+
+	switch suffix {
+	case "end", "ung":
+		w = w[:start]
+		if strings.HasSuffix(string(w), "ig") {
+			igStart := len(w) - 2
+			if igStart >= p2 && (igStart == 0 || w[igStart-1] != 'e') {
+				w = w[:igStart]
+			}
+		}
+	case "ig", "ik", "isch":
+		if !precededByE {
+			w = w[:start]
+		}
+	case "lich", "heit":
+		w = w[:start]
+		if s := longestSuffix(w, "er", "en"); s != "" && len(w)-2 >= p1 {
+			w = w[:len(w)-2]
+		}
+	case "keit":
+		w = w[:start]
+		if s := longestSuffix(w, "lich", "ig"); s != "" && len(w)-len(s) >= p2 {
+			w = w[:len(w)-len(s)]
+		}
+	}
+	return w
+}
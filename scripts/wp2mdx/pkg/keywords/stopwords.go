@@ -0,0 +1,54 @@
+package keywords
+
+import "strings"
+
+// stopwords lists German function words and filler words that never make
+// useful keywords. It extends the Snowball German stopword list with
+// adverbs and verbs common in blog prose.
+var stopwords = makeSet(`
+aber alle allem allen aller alles als also am an ander andere anderem anderen
+anderer anderes anderm andern anderr anders auch auf aus bei bin bis bist da
+damit dann der den des dem die das dass daß derselbe derselben denselben
+desselben demselben dieselbe dieselben dasselbe dazu dein deine deinem deinen
+deiner deines denn derer dessen dich dir du dies diese diesem diesen dieser
+dieses doch dort durch ein eine einem einen einer eines einig einige einigem
+einigen einiger einiges einmal er ihn ihm es etwas euer eure eurem euren eurer
+eures für gegen gewesen hab habe haben hat hatte hatten hier hin hinter ich
+mich mir ihr ihre ihrem ihren ihrer ihres euch im in indem ins ist jede jedem
+jeden jeder jedes jene jenem jenen jener jenes jetzt kann kein keine keinem
+keinen keiner keines können könnte machen man manche manchem manchen mancher
+manches mein meine meinem meinen meiner meines mit muss musste nach nicht
+nichts noch nun nur ob oder ohne sehr sein seine seinem seinen seiner seines
+selbst sich sie ihnen sind so solche solchem solchen solcher solches soll
+sollte sondern sonst über um und uns unsere unserem unseren unser unseres
+unter viel vom von vor während war waren warst was weg weil weiter welche
+welchem welchen welcher welches wenn werde werden wie wieder will wir wird
+wirst wo wollen wollte würde würden zu zum zur zwar zwischen
+
+ab allerdings bereits beim besonders bzw ca dabei dadurch dafür daher darauf
+daraus darüber darum davon dazu dennoch deshalb deswegen eigentlich einfach
+einen etwa ganz ganze ganzen gar gibt gerade gleich gut häufig heißt hinaus
+immer insbesondere je jedoch kannst kaum lassen lässt mal mehr mehrere meist
+meisten möglich möglichst müssen musst nämlich neben nie oft sehr sogar
+sollen solltest sollten somit sowie sowohl statt trotz usw vielleicht viele
+vielen vieler weitere weiteren wegen weniger wäre wären wurde wurden zudem
+zusammen schon hast hätte hätten kannst könnten darf dürfen denen deren
+erst ersten etwa euch fast ggf hierbei hierfür jeweils laut mithilfe ohnehin
+per pro sei seien seit seitdem selten sodass stets teils überhaupt übrigens
+unten oben vorher wann warum weshalb wieso wobei wodurch woher wohl womit
+zB bzw
+`)
+
+// makeSet builds a set from whitespace-separated words
+func makeSet(words string) map[string]bool {
+	set := make(map[string]bool)
+	for _, word := range strings.Fields(words) {
+		set[strings.ToLower(word)] = true
+	}
+	return set
+}
+
+// IsStopword reports whether a lowercase word is a German stopword
+func IsStopword(word string) bool {
+	return stopwords[word]
+}
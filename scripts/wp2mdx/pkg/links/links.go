@@ -0,0 +1,169 @@
+// Package links maps WordPress permalinks to the routes of the converted
+// posts, so links between posts keep working after the migration.
+package links
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/aequinox/gesundes-leben/wp2mdx/pkg/models"
+	"github.com/aequinox/gesundes-leben/wp2mdx/pkg/parser"
+)
+
+// PostsPath is the route prefix of blog posts on the Astro site
+const PostsPath = "/posts/"
+
+// Target is an item of the export that links may point to
+type Target struct {
+	ID     int
+	Title  string
+	Type   string
+	Status string
+	Slug   string
+	Link   string
+	Parent int
+	// Converted is set for items written by this run
+	Converted bool
+}
+
+// Route returns the site-relative route of the converted post. The site
+// derives it from the title, see PostSlug.
+func (t *Target) Route() string {
+	return PostsPath + PostSlug(t.Title) + "/"
+}
+
+// Published reports whether the target is publicly visible
+func (t *Target) Published() bool {
+	return t.Status == "publish"
+}
+
+// Index maps the permalink variants of every item in an export to the item
+type Index struct {
+	hosts   map[string]bool
+	targets []*Target
+	byPath  map[string]*Target
+	byID    map[int]*Target
+}
+
+// NewIndex creates an empty index
+func NewIndex() *Index {
+	return &Index{
+		hosts:  make(map[string]bool),
+		byPath: make(map[string]*Target),
+		byID:   make(map[int]*Target),
+	}
+}
+
+// SetSiteURL sets the site the export comes from. Links to its host, with
+// or without "www.", and site-relative links are internal.
+func (x *Index) SetSiteURL(siteURL string) {
+	if u, err := url.Parse(strings.TrimSpace(siteURL)); err == nil && u.Host != "" {
+		host := strings.TrimPrefix(strings.ToLower(u.Host), "www.")
+		x.hosts[host] = true
+		x.hosts["www."+host] = true
+	}
+}
+
+// Add indexes an item by its permalink, its GUID and "?p=ID" link, and its
+// post_name. Paths already taken by an earlier item are not overwritten.
+func (x *Index) Add(item *models.Item) *Target {
+	t := &Target{
+		ID:     item.PostID,
+		Title:  item.Title,
+		Type:   item.PostType,
+		Status: item.Status,
+		Slug:   parser.GetSlug(item),
+		Link:   strings.TrimSpace(item.Link),
+		Parent: item.PostParent,
+	}
+	x.targets = append(x.targets, t)
+	if t.ID != 0 {
+		x.byID[t.ID] = t
+	}
+
+	for _, link := range []string{t.Link, strings.TrimSpace(item.GUID)} {
+		if u, err := url.Parse(link); err == nil && u.RawQuery == "" {
+			x.addPath(u.Path, t)
+		}
+	}
+	if item.PostName != "" {
+		x.addPath("/"+item.PostName, t)
+	}
+
+	return t
+}
+
+// addPath indexes a normalized path
+func (x *Index) addPath(path string, t *Target) {
+	key := normalizePath(path)
+	if key == "" {
+		return
+	}
+	if _, ok := x.byPath[key]; !ok {
+		x.byPath[key] = t
+	}
+}
+
+// Targets returns all indexed items in the order they were added
+func (x *Index) Targets() []*Target {
+	return x.targets
+}
+
+// ByID returns the item with the given post ID
+func (x *Index) ByID(id int) *Target {
+	return x.byID[id]
+}
+
+// Resolve looks up the item an href points to. internal reports whether the
+// href points to the site at all; uploads and admin URLs are not content
+// and count as external. fragment is the "#..." part of the href, if any.
+func (x *Index) Resolve(href string) (target *Target, fragment string, internal bool) {
+	u, err := url.Parse(strings.TrimSpace(href))
+	if err != nil {
+		return nil, "", false
+	}
+
+	switch {
+	case u.Scheme == "" && u.Host == "":
+		if !strings.HasPrefix(u.Path, "/") {
+			// Anchors and relative links
+			return nil, "", false
+		}
+	case u.Scheme != "http" && u.Scheme != "https" && u.Scheme != "":
+		return nil, "", false
+	case !x.hosts[strings.ToLower(u.Host)]:
+		return nil, "", false
+	}
+
+	if strings.HasPrefix(u.Path, "/wp-content/") || strings.HasPrefix(u.Path, "/wp-admin/") ||
+		strings.HasPrefix(u.Path, "/wp-includes/") || strings.HasPrefix(u.Path, "/wp-json/") {
+		return nil, "", false
+	}
+	if u.Fragment != "" {
+		fragment = "#" + u.Fragment
+	}
+
+	query := u.Query()
+	for _, key := range []string{"p", "page_id", "attachment_id"} {
+		if id, err := strconv.Atoi(query.Get(key)); err == nil {
+			return x.byID[id], fragment, true
+		}
+	}
+
+	key := normalizePath(u.Path)
+	if key == "" {
+		// The home page is not content of the export
+		return nil, "", false
+	}
+	return x.byPath[key], fragment, true
+}
+
+// normalizePath lowercases a path and trims slashes, so that
+// "/Foo/" and "/foo" are the same key. The root path yields "".
+func normalizePath(path string) string {
+	if unescaped, err := url.PathUnescape(path); err == nil {
+		path = unescaped
+	}
+	return strings.Trim(strings.ToLower(path), "/")
+}
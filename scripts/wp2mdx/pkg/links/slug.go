@@ -0,0 +1,82 @@
+package links
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// datePrefix matches the date some titles start with, e.g. "2024-03-01 "
+var datePrefix = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}[-\s]\s*`)
+
+// germanChars are the transliterations of the slugify package's German
+// locale; they take precedence over latinChars
+var germanChars = map[rune]string{
+	'Ä': "AE", 'ä': "ae", 'Ö': "OE", 'ö': "oe", 'Ü': "UE", 'ü': "ue", 'ß': "ss",
+	'%': "prozent", '&': "und", '|': "oder",
+	'∑': "summe", '∞': "unendlich", '♥': "liebe",
+}
+
+// latinChars are the transliterations of the slugify package's character
+// map for Latin letters and common symbols
+var latinChars = map[rune]string{
+	'$': "dollar", '<': "less", '>': "greater",
+	'¢': "cent", '£': "pound", '¤': "currency", '¥': "yen", '€': "euro",
+	'©': "(c)", '®': "(r)", '™': "tm", 'ª': "a", 'º': "o",
+	'À': "A", 'Á': "A", 'Â': "A", 'Ã': "A", 'Å': "A", 'Æ': "AE", 'Ç': "C",
+	'È': "E", 'É': "E", 'Ê': "E", 'Ë': "E", 'Ì': "I", 'Í': "I", 'Î': "I", 'Ï': "I",
+	'Ð': "D", 'Ñ': "N", 'Ò': "O", 'Ó': "O", 'Ô': "O", 'Õ': "O", 'Ø': "O",
+	'Ù': "U", 'Ú': "U", 'Û': "U", 'Ý': "Y", 'Þ': "TH",
+	'à': "a", 'á': "a", 'â': "a", 'ã': "a", 'å': "a", 'æ': "ae", 'ç': "c",
+	'è': "e", 'é': "e", 'ê': "e", 'ë': "e", 'ì': "i", 'í': "i", 'î': "i", 'ï': "i",
+	'ð': "d", 'ñ': "n", 'ò': "o", 'ó': "o", 'ô': "o", 'õ': "o", 'ø': "o",
+	'ù': "u", 'ú': "u", 'û': "u", 'ý': "y", 'þ': "th", 'ÿ': "y",
+	'Ā': "A", 'ā': "a", 'Ă': "A", 'ă': "a", 'Ą': "A", 'ą': "a",
+	'Ć': "C", 'ć': "c", 'Č': "C", 'č': "c", 'Ď': "D", 'ď': "d", 'Đ': "DJ", 'đ': "dj",
+	'Ē': "E", 'ē': "e", 'Ė': "E", 'ė': "e", 'Ę': "e", 'ę': "e", 'Ě': "E", 'ě': "e",
+	'Ğ': "G", 'ğ': "g", 'Ģ': "G", 'ģ': "g", 'Ī': "i", 'ī': "i", 'Į': "I", 'į': "i",
+	'İ': "I", 'ı': "i", 'Ķ': "k", 'ķ': "k", 'Ļ': "L", 'ļ': "l", 'Ľ': "L", 'ľ': "l",
+	'Ł': "L", 'ł': "l", 'Ń': "N", 'ń': "n", 'Ņ': "N", 'ņ': "n", 'Ň': "N", 'ň': "n",
+	'Ō': "O", 'ō': "o", 'Ő': "O", 'ő': "o", 'Œ': "OE", 'œ': "oe",
+	'Ŕ': "R", 'ŕ': "r", 'Ř': "R", 'ř': "r", 'Ś': "S", 'ś': "s", 'Ş': "S", 'ş': "s",
+	'Š': "S", 'š': "s", 'Ţ': "T", 'ţ': "t", 'Ť': "T", 'ť': "t",
+	'Ū': "u", 'ū': "u", 'Ů': "U", 'ů': "u", 'Ű': "U", 'ű': "u", 'Ų': "U", 'ų': "u",
+	'Ÿ': "Y", 'Ź': "Z", 'ź': "z", 'Ż': "Z", 'ż': "z", 'Ž': "Z", 'ž': "z",
+}
+
+// Slugify converts text to a slug the way the site's slugify() does
+// (src/utils/slugs.ts): the slugify package with the German locale in
+// strict mode. Letters are transliterated, "&" becomes "und", hyphens and
+// whitespace separate words and all other characters are dropped, so
+// "Darm & Hirn: Wie?" becomes "darm-und-hirn-wie".
+func Slugify(text string) string {
+	var sb strings.Builder
+	for _, r := range text {
+		s, ok := germanChars[r]
+		if !ok {
+			s, ok = latinChars[r]
+		}
+		if !ok {
+			s = string(r)
+		}
+		if s == "-" {
+			s = " "
+		}
+		for _, c := range s {
+			switch {
+			case c < unicode.MaxASCII && (unicode.IsLetter(c) || unicode.IsDigit(c)):
+				sb.WriteRune(c)
+			case unicode.IsSpace(c):
+				sb.WriteByte(' ')
+			}
+		}
+	}
+	return strings.ToLower(strings.Join(strings.Fields(sb.String()), "-"))
+}
+
+// PostSlug returns the slug the site routes a post under: its title
+// without a leading date, slugified (getPostSlug in src/utils/slugs.ts).
+// The WordPress post_name plays no part.
+func PostSlug(title string) string {
+	return Slugify(datePrefix.ReplaceAllString(strings.TrimSpace(title), ""))
+}
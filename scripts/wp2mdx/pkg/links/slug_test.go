@@ -0,0 +1,31 @@
+package links
+
+import "testing"
+
+func TestPostSlug(t *testing.T) {
+	tests := []struct {
+		title string
+		want  string
+	}{
+		{"7 grundlegende Gefahren von Mikroplastik für deine Gesundheit", "7-grundlegende-gefahren-von-mikroplastik-fuer-deine-gesundheit"},
+		{"Darm & Hirn: Wie hängen sie zusammen?", "darm-und-hirn-wie-haengen-sie-zusammen"},
+		{"Omega-3-Fettsäuren – Fluch oder Segen?", "omega-3-fettsaeuren-fluch-oder-segen"},
+		{"2024-03-01 Stress im Alltag", "stress-im-alltag"},
+		{"Café Crème für 100 % Genuss", "cafe-creme-fuer-100-prozent-genuss"},
+		{"Vitamin D3/K2 „Sonnenvitamin“", "vitamin-d3k2-sonnenvitamin"},
+		{"  ", ""},
+	}
+
+	for _, tt := range tests {
+		if got := PostSlug(tt.title); got != tt.want {
+			t.Errorf("PostSlug(%q) = %q, want %q", tt.title, got, tt.want)
+		}
+	}
+}
+
+func TestRoute(t *testing.T) {
+	target := &Target{Title: "Schlaf & Regeneration", Slug: "schlaf-regeneration-tipps"}
+	if got, want := target.Route(), "/posts/schlaf-und-regeneration/"; got != want {
+		t.Errorf("Route() = %q, want %q", got, want)
+	}
+}
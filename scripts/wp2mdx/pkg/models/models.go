@@ -1,6 +1,9 @@
 package models
 
-import "time"
+import (
+	"strconv"
+	"time"
+)
 
 // WordPressExport represents the root WordPress export structure
 type WordPressExport struct {
@@ -15,6 +18,17 @@ type Channel struct {
 	Language    string   `xml:"language"`
 	Items       []Item   `xml:"item"`
 	Authors     []Author `xml:"author"`
+	// Terms are the category, tag and custom taxonomy terms defined in the
+	// export; they are only read when streaming
+	Terms []Term `xml:"-"`
+}
+
+// Term is a taxonomy term defined at channel level (wp:category, wp:tag or
+// wp:term)
+type Term struct {
+	Taxonomy string
+	Slug     string
+	Name     string
 }
 
 // Author represents a WordPress author
@@ -29,23 +43,45 @@ type Author struct {
 
 // Item represents a WordPress post/page/attachment
 type Item struct {
-	Title       string     `xml:"title"`
-	Link        string     `xml:"link"`
-	PubDate     string     `xml:"pubDate"`
-	Creator     string     `xml:"http://purl.org/dc/elements/1.1/ creator"`
-	GUID        string     `xml:"guid"`
-	Description string     `xml:"description"`
-	Content     string     `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
-	Excerpt     string     `xml:"http://wordpress.org/export/1.2/excerpt/ encoded"`
-	PostID      int        `xml:"http://wordpress.org/export/1.2/ post_id"`
-	PostName    string     `xml:"http://wordpress.org/export/1.2/ post_name"`
-	PostType    string     `xml:"http://wordpress.org/export/1.2/ post_type"`
-	Status      string     `xml:"http://wordpress.org/export/1.2/ status"`
-	PostParent  int        `xml:"http://wordpress.org/export/1.2/ post_parent"`
-	IsSticky    int        `xml:"http://wordpress.org/export/1.2/ is_sticky"`
-	Categories  []Category `xml:"category"`
-	PostMeta    []PostMeta `xml:"http://wordpress.org/export/1.2/ postmeta"`
-	Comments    []Comment  `xml:"http://wordpress.org/export/1.2/ comment"`
+	Title         string     `xml:"title"`
+	Link          string     `xml:"link"`
+	PubDate       string     `xml:"pubDate"`
+	PostDate      string     `xml:"http://wordpress.org/export/1.2/ post_date"`
+	PostDateGMT   string     `xml:"http://wordpress.org/export/1.2/ post_date_gmt"`
+	Modified      string     `xml:"http://wordpress.org/export/1.2/ post_modified"`
+	ModifiedGMT   string     `xml:"http://wordpress.org/export/1.2/ post_modified_gmt"`
+	Creator       string     `xml:"http://purl.org/dc/elements/1.1/ creator"`
+	GUID          string     `xml:"guid"`
+	Description   string     `xml:"description"`
+	Content       string     `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+	Excerpt       string     `xml:"http://wordpress.org/export/1.2/excerpt/ encoded"`
+	PostID        int        `xml:"http://wordpress.org/export/1.2/ post_id"`
+	PostName      string     `xml:"http://wordpress.org/export/1.2/ post_name"`
+	PostType      string     `xml:"http://wordpress.org/export/1.2/ post_type"`
+	Status        string     `xml:"http://wordpress.org/export/1.2/ status"`
+	PostParent    int        `xml:"http://wordpress.org/export/1.2/ post_parent"`
+	IsSticky      int        `xml:"http://wordpress.org/export/1.2/ is_sticky"`
+	AttachmentURL string     `xml:"http://wordpress.org/export/1.2/ attachment_url"`
+	Categories    []Category `xml:"category"`
+	PostMeta      []PostMeta `xml:"http://wordpress.org/export/1.2/ postmeta"`
+	Comments      []Comment  `xml:"http://wordpress.org/export/1.2/ comment"`
+}
+
+// Attachment is a lightweight view of an attachment item, kept in memory
+// while the rest of the export is streamed
+type Attachment struct {
+	ID          int
+	ParentID    int
+	Title       string
+	Name        string
+	GUID        string
+	URL         string
+	Alt         string
+	Caption     string
+	Description string
+	Width       int
+	Height      int
+	Meta        map[string]string
 }
 
 // Category represents a WordPress category or tag
@@ -76,26 +112,33 @@ type Comment struct {
 
 // Post represents a processed blog post ready for MDX generation
 type Post struct {
-	ID          string
-	Title       string
-	Slug        string
-	Author      string
-	Content     string
-	Excerpt     string
-	PubDate     time.Time
-	ModDate     time.Time
-	Status      string
-	Type        string
-	Categories  []string
-	Tags        []string
-	Keywords    []string
-	Group       string
-	Featured    bool
-	Draft       bool
-	HeroImage   *ImageRef
-	Images      []ImageRef
-	Frontmatter map[string]interface{}
-	RawItem     *Item
+	ID              string
+	Title           string
+	Slug            string
+	Author          string
+	Content         string
+	Excerpt         string
+	PubDate         time.Time
+	ModDate         time.Time
+	Status          string
+	Type            string
+	Categories      []string
+	Tags            []string
+	Keywords        []string
+	Group           string
+	GroupConfidence float64
+	GroupRule       string
+	Featured        bool
+	Draft           bool
+	HeroImage       *ImageRef
+	OGImage         *ImageRef
+	Images          []ImageRef
+	References      []string
+	GlossaryTerms   []string
+	SEO             SEOMeta
+	Frontmatter     map[string]interface{}
+	RawItem         *Item
+	Warnings        []string
 }
 
 // ImageRef represents an image reference in the post
@@ -105,28 +148,62 @@ type ImageRef struct {
 	LocalPath    string
 	Variable     string
 	Alt          string
+	Caption      string
 	Position     string
 	OriginalName string
+	Width        int
+	Height       int
 	Downloaded   bool
 }
 
+// ApplyAttachment links the image to its WordPress attachment, filling alt
+// text and caption where the reference has none
+func (r *ImageRef) ApplyAttachment(a *Attachment) {
+	r.ID = strconv.Itoa(a.ID)
+	if r.Alt == "" {
+		r.Alt = a.Alt
+	}
+	if r.Caption == "" {
+		r.Caption = a.Caption
+	}
+	if a.Width > 0 {
+		r.Width, r.Height = a.Width, a.Height
+	}
+}
+
 // Frontmatter represents the MDX frontmatter structure
 type Frontmatter struct {
-	ID          string                 `yaml:"id"`
-	Title       string                 `yaml:"title"`
-	Author      string                 `yaml:"author"`
-	PubDatetime string                 `yaml:"pubDatetime"`
-	ModDatetime string                 `yaml:"modDatetime"`
-	Description string                 `yaml:"description"`
-	Keywords    []string               `yaml:"keywords,omitempty"`
-	Categories  []string               `yaml:"categories,omitempty"`
-	Group       string                 `yaml:"group"`
-	Tags        []string               `yaml:"tags,omitempty"`
-	HeroImage   *HeroImage             `yaml:"heroImage,omitempty"`
-	Draft       bool                   `yaml:"draft"`
-	Featured    bool                   `yaml:"featured"`
-	References  []string               `yaml:"references,omitempty"`
-	Extra       map[string]interface{} `yaml:",inline"`
+	ID           string                 `yaml:"id"`
+	Title        string                 `yaml:"title"`
+	Author       string                 `yaml:"author"`
+	PubDatetime  string                 `yaml:"pubDatetime"`
+	ModDatetime  string                 `yaml:"modDatetime"`
+	Timezone     string                 `yaml:"timezone,omitempty"`
+	Description  string                 `yaml:"description"`
+	Keywords     []string               `yaml:"keywords,omitempty"`
+	Categories   []string               `yaml:"categories,omitempty"`
+	Group        string                 `yaml:"group"`
+	Tags         []string               `yaml:"tags,omitempty"`
+	HeroImage    *HeroImage             `yaml:"heroImage,omitempty"`
+	OGImage      string                 `yaml:"ogImage,omitempty"`
+	CanonicalURL string                 `yaml:"canonicalURL,omitempty"`
+	ReadingTime  int                    `yaml:"readingTime,omitempty"`
+	Draft        bool                   `yaml:"draft"`
+	Featured     bool                   `yaml:"featured"`
+	References   []string               `yaml:"references,omitempty"`
+	Extra        map[string]interface{} `yaml:",inline"`
+}
+
+// SEOMeta holds the metadata an SEO plugin (Yoast or Rank Math) stored for
+// a post. Source names the plugin; empty fields were not set.
+type SEOMeta struct {
+	Source       string
+	Title        string
+	Description  string
+	Keywords     []string
+	CanonicalURL string
+	OGImageURL   string
+	OGImageID    int
 }
 
 // HeroImage represents the hero image configuration
@@ -140,7 +217,10 @@ type ConversionStats struct {
 	PostsProcessed   int
 	PostsSkipped     int
 	ImagesDownloaded int
+	ImagesReused     int
 	ImagesFailed     int
+	ImageBytes       int64
+	ImageBytesSaved  int64
 	Errors           []error
 	StartTime        time.Time
 	EndTime          time.Time
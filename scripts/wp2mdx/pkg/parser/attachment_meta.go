@@ -0,0 +1,42 @@
+package parser
+
+import (
+	"html"
+	"regexp"
+	"strings"
+
+	"github.com/aequinox/gesundes-leben/wp2mdx/pkg/models"
+)
+
+// resolveAttachmentMeta fills alt text, caption, description and image
+// dimensions from the attachment item and its postmeta
+func resolveAttachmentMeta(attachment *models.Attachment, item *models.Item) {
+	attachment.Alt = strings.TrimSpace(html.UnescapeString(attachment.Meta["_wp_attachment_image_alt"]))
+	attachment.Caption = strings.TrimSpace(item.Excerpt)
+	attachment.Description = strings.TrimSpace(item.Content)
+
+	meta, err := Unserialize(strings.TrimSpace(attachment.Meta["_wp_attachment_metadata"]))
+	if err != nil {
+		return
+	}
+	width, wOK := PHPInt(PHPLookup(meta, "width"))
+	height, hOK := PHPInt(PHPLookup(meta, "height"))
+	if wOK && hOK {
+		attachment.Width, attachment.Height = width, height
+	}
+}
+
+// filenameAltRe matches alt text that looks like an upload's file name: an
+// image extension, a size suffix, camera or stock photo numbering, or
+// underscores instead of spaces
+var filenameAltRe = regexp.MustCompile(`(?i)(\.(jpe?g|png|gif|webp|avif|svg|heic)$|-\d+x\d+$|\d{4,}|_)`)
+
+// IsFilenameLikeAlt reports whether alt text was left at a file name such as
+// "Depositphotos_97583692" or "IMG_1234.jpg" instead of describing the image
+func IsFilenameLikeAlt(alt string) bool {
+	alt = strings.TrimSpace(alt)
+	if alt == "" || strings.ContainsAny(alt, " \t") {
+		return false
+	}
+	return filenameAltRe.MatchString(alt)
+}
@@ -0,0 +1,170 @@
+package parser
+
+import (
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/aequinox/gesundes-leben/wp2mdx/pkg/models"
+)
+
+// AttachmentIndex holds a lightweight copy of every attachment in the export.
+// It is built once per export and answers lookups by post ID, GUID and
+// attachment URL, including the resized variants WordPress generates.
+type AttachmentIndex struct {
+	byID   map[int]*models.Attachment
+	byGUID map[string]*models.Attachment
+	byURL  map[string]*models.Attachment
+}
+
+// attachmentMetaKeys lists the attachment postmeta worth keeping in the index
+var attachmentMetaKeys = map[string]bool{
+	"_wp_attached_file":        true,
+	"_wp_attachment_image_alt": true,
+	"_wp_attachment_metadata":  true,
+}
+
+// sizeSuffixRe matches the suffixes WordPress appends to derived image files,
+// e.g. "-677x1024", "-scaled" or "-rotated"
+var sizeSuffixRe = regexp.MustCompile(`(-\d+x\d+|-scaled|-rotated)$`)
+
+// NewAttachmentIndex creates an empty attachment index
+func NewAttachmentIndex() *AttachmentIndex {
+	return &AttachmentIndex{
+		byID:   make(map[int]*models.Attachment),
+		byGUID: make(map[string]*models.Attachment),
+		byURL:  make(map[string]*models.Attachment),
+	}
+}
+
+// BuildAttachmentIndex indexes all attachments in a fully parsed item list
+func BuildAttachmentIndex(items []models.Item) *AttachmentIndex {
+	idx := NewAttachmentIndex()
+	for i := range items {
+		idx.Add(&items[i])
+	}
+	return idx
+}
+
+// Add stores an attachment item in the index. Other post types are ignored.
+func (idx *AttachmentIndex) Add(item *models.Item) {
+	if item.PostType != "attachment" {
+		return
+	}
+
+	attachment := &models.Attachment{
+		ID:       item.PostID,
+		ParentID: item.PostParent,
+		Title:    item.Title,
+		Name:     item.PostName,
+		GUID:     strings.TrimSpace(item.GUID),
+		URL:      strings.TrimSpace(item.AttachmentURL),
+		Meta:     make(map[string]string),
+	}
+
+	for _, meta := range item.PostMeta {
+		if attachmentMetaKeys[meta.Key] {
+			attachment.Meta[meta.Key] = meta.Value
+		}
+	}
+
+	if attachment.URL == "" {
+		attachment.URL = attachment.GUID
+	}
+
+	resolveAttachmentMeta(attachment, item)
+
+	idx.byID[attachment.ID] = attachment
+	if attachment.GUID != "" {
+		idx.byGUID[attachment.GUID] = attachment
+	}
+
+	// Register every URL form the attachment may be referenced by
+	for _, u := range []string{attachment.URL, attachment.GUID, attachment.Meta["_wp_attached_file"]} {
+		if key := UploadKey(u); key != "" {
+			if _, exists := idx.byURL[key]; !exists {
+				idx.byURL[key] = attachment
+			}
+		}
+	}
+}
+
+// ByID looks up an attachment by its post ID
+func (idx *AttachmentIndex) ByID(id int) *models.Attachment {
+	if idx == nil {
+		return nil
+	}
+	return idx.byID[id]
+}
+
+// ByGUID looks up an attachment by its GUID
+func (idx *AttachmentIndex) ByGUID(guid string) *models.Attachment {
+	if idx == nil {
+		return nil
+	}
+	return idx.byGUID[strings.TrimSpace(guid)]
+}
+
+// ByURL looks up an attachment by any URL pointing at it, including resized
+// variants such as "photo-677x1024.jpg" or "photo-scaled.jpg"
+func (idx *AttachmentIndex) ByURL(url string) *models.Attachment {
+	if idx == nil {
+		return nil
+	}
+	if attachment := idx.ByGUID(url); attachment != nil {
+		return attachment
+	}
+	return idx.byURL[UploadKey(url)]
+}
+
+// Len returns the number of indexed attachments
+func (idx *AttachmentIndex) Len() int {
+	if idx == nil {
+		return 0
+	}
+	return len(idx.byID)
+}
+
+// UploadKey reduces an upload URL to a host-independent path relative to
+// the uploads directory with WordPress size suffixes removed, so all size
+// variants of one upload share one key, e.g. "2024/03/photo.jpg" for
+// "https://example.com/wp-content/uploads/2024/03/photo-300x200.JPG"
+func UploadKey(url string) string {
+	url = strings.TrimSpace(url)
+	if url == "" {
+		return ""
+	}
+
+	// Remove query string and fragment
+	if i := strings.IndexAny(url, "?#"); i != -1 {
+		url = url[:i]
+	}
+
+	// Strip scheme and host
+	if i := strings.Index(url, "//"); i != -1 {
+		url = url[i+2:]
+		if j := strings.Index(url, "/"); j != -1 {
+			url = url[j:]
+		} else {
+			return ""
+		}
+	}
+
+	// Keep only the part relative to the uploads directory
+	if i := strings.Index(url, "/uploads/"); i != -1 {
+		url = url[i+len("/uploads/"):]
+	}
+	url = strings.TrimPrefix(url, "/")
+
+	ext := path.Ext(url)
+	base := strings.TrimSuffix(url, ext)
+	for {
+		stripped := sizeSuffixRe.ReplaceAllString(base, "")
+		if stripped == base {
+			break
+		}
+		base = stripped
+	}
+
+	return base + strings.ToLower(ext)
+}
@@ -0,0 +1,21 @@
+package parser
+
+import "testing"
+
+func TestUploadKey(t *testing.T) {
+	want := "2024/03/photo.jpg"
+	for _, url := range []string{
+		"https://example.com/wp-content/uploads/2024/03/photo.jpg",
+		"http://www.example.com/wp-content/uploads/2024/03/photo-300x200.jpg",
+		"https://cdn.example.org/wp-content/uploads/2024/03/photo-scaled.JPG?ver=2",
+		"/wp-content/uploads/2024/03/photo-rotated-1024x683.jpg#top",
+	} {
+		if got := UploadKey(url); got != want {
+			t.Errorf("UploadKey(%q) = %q, want %q", url, got, want)
+		}
+	}
+
+	if got := UploadKey("https://example.com"); got != "" {
+		t.Errorf("UploadKey() of a bare host = %q, want \"\"", got)
+	}
+}
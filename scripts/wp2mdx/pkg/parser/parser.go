@@ -41,46 +41,63 @@ func (p *Parser) Parse() (*models.WordPressExport, error) {
 func FilterPosts(items []models.Item, includeDrafts, includePages, includeTypes bool) []models.Item {
 	var filtered []models.Item
 
-	for _, item := range items {
-		// Skip attachments, revisions, etc.
-		if item.PostType == "attachment" ||
-			item.PostType == "revision" ||
-			item.PostType == "nav_menu_item" ||
-			item.PostType == "custom_css" ||
-			item.PostType == "customize_changeset" {
-			continue
+	for i := range items {
+		if IncludePost(&items[i], includeDrafts, includePages, includeTypes) {
+			filtered = append(filtered, items[i])
 		}
+	}
 
-		// Skip trash
-		if item.Status == "trash" {
-			continue
-		}
+	return filtered
+}
 
-		// Handle drafts
-		if item.Status == "draft" && !includeDrafts {
-			continue
-		}
+// IncludePost reports whether a single item passes the post filters. It is
+// used directly when consuming a stream of items.
+func IncludePost(item *models.Item, includeDrafts, includePages, includeTypes bool) bool {
+	// Skip attachments, revisions, etc.
+	if item.PostType == "attachment" ||
+		item.PostType == "revision" ||
+		item.PostType == "nav_menu_item" ||
+		item.PostType == "custom_css" ||
+		item.PostType == "customize_changeset" {
+		return false
+	}
 
-		// Handle pages
-		if item.PostType == "page" && !includePages {
-			continue
-		}
+	// Skip trash
+	if item.Status == "trash" {
+		return false
+	}
 
-		// Handle custom post types
-		if item.PostType != "post" && item.PostType != "page" && !includeTypes {
-			continue
-		}
+	// Handle drafts
+	if item.Status == "draft" && !includeDrafts {
+		return false
+	}
 
-		filtered = append(filtered, item)
+	// Handle pages
+	if item.PostType == "page" && !includePages {
+		return false
 	}
 
-	return filtered
+	// Handle custom post types
+	if item.PostType != "post" && item.PostType != "page" && !includeTypes {
+		return false
+	}
+
+	return true
 }
 
-// ParseDate parses various WordPress date formats
+// ParseDate parses various WordPress date formats. Dates without a zone
+// are taken as UTC.
 func ParseDate(dateStr string) (time.Time, error) {
-	if dateStr == "" {
-		return time.Now(), nil
+	return ParseDateIn(dateStr, time.UTC)
+}
+
+// ParseDateIn parses various WordPress date formats, taking dates without a
+// zone in loc. Empty dates and the zero date WordPress stores for unpublished
+// posts are an error.
+func ParseDateIn(dateStr string, loc *time.Location) (time.Time, error) {
+	dateStr = strings.TrimSpace(dateStr)
+	if dateStr == "" || strings.HasPrefix(dateStr, "0000-00-00") {
+		return time.Time{}, fmt.Errorf("empty date")
 	}
 
 	formats := []string{
@@ -93,7 +110,7 @@ func ParseDate(dateStr string) (time.Time, error) {
 	}
 
 	for _, format := range formats {
-		if t, err := time.Parse(format, dateStr); err == nil {
+		if t, err := time.ParseInLocation(format, dateStr, loc); err == nil {
 			return t, nil
 		}
 	}
@@ -101,6 +118,40 @@ func ParseDate(dateStr string) (time.Time, error) {
 	return time.Time{}, fmt.Errorf("unable to parse date: %s", dateStr)
 }
 
+// GetPostDates returns the publication and last modification time of an
+// item. The GMT columns are preferred; local times are interpreted in the
+// site timezone loc. Both times are returned in loc, so that dates in file
+// names match the site. A missing modification time defaults to the
+// publication time.
+func GetPostDates(item *models.Item, loc *time.Location) (published, modified time.Time, err error) {
+	published, err = firstDate(loc, item.PostDateGMT, item.PostDate, item.PubDate)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("no publication date: %w", err)
+	}
+
+	modified, err = firstDate(loc, item.ModifiedGMT, item.Modified)
+	if err != nil || modified.Before(published) {
+		modified = published
+	}
+
+	return published.In(loc), modified.In(loc), nil
+}
+
+// firstDate parses the first usable date; the first entry is GMT, the
+// others are local or carry their own zone
+func firstDate(loc *time.Location, gmt string, others ...string) (time.Time, error) {
+	t, err := ParseDate(gmt)
+	if err == nil {
+		return t, nil
+	}
+	for _, s := range others {
+		if t, err = ParseDateIn(s, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, err
+}
+
 // GetPostMeta retrieves a specific post meta value
 func GetPostMeta(item *models.Item, key string) string {
 	for _, meta := range item.PostMeta {
@@ -170,67 +221,13 @@ func GenerateSlug(title string) string {
 	return slug
 }
 
-// ExtractKeywords extracts keywords from content
-func ExtractKeywords(content string, maxKeywords int) []string {
-	// Simple keyword extraction - can be enhanced
-	words := strings.Fields(content)
-	wordCount := make(map[string]int)
-
-	// Common German stop words
-	stopWords := map[string]bool{
-		"der": true, "die": true, "das": true, "und": true, "ist": true,
-		"in": true, "zu": true, "den": true, "von": true, "mit": true,
-		"ein": true, "eine": true, "für": true, "auf": true, "des": true,
-		"sich": true, "werden": true, "dem": true, "nicht": true, "hat": true,
-		"im": true, "am": true, "an": true, "als": true, "auch": true,
-	}
-
-	for _, word := range words {
-		word = strings.ToLower(strings.Trim(word, ".,!?;:()[]{}\"'"))
-		if len(word) > 3 && !stopWords[word] {
-			wordCount[word]++
-		}
-	}
-
-	// Sort by frequency (simplified - just get the top ones)
-	var keywords []string
-	for word := range wordCount {
-		if len(keywords) < maxKeywords {
-			keywords = append(keywords, word)
-		}
-	}
-
-	return keywords
-}
-
-// DetermineGroup determines the post group based on content
-func DetermineGroup(title, content string) string {
-	titleLower := strings.ToLower(title)
-	contentLower := strings.ToLower(content)
-
-	// Check for "kontra" indicators (warnings, dangers, risks)
-	kontraWords := []string{"gefahr", "risiko", "warnung", "vorsicht", "nachteil", "problem", "schaden"}
-	for _, word := range kontraWords {
-		if strings.Contains(titleLower, word) || strings.Contains(contentLower[:min(500, len(contentLower))], word) {
-			return "kontra"
-		}
-	}
-
-	// Check for "fragezeiten" indicators (questions, FAQ)
-	if strings.Contains(titleLower, "?") || strings.Contains(titleLower, "frage") || strings.Contains(titleLower, "warum") {
-		return "fragezeiten"
+// GetSlug returns the slug of an item: its post_name, or one generated from
+// the title for drafts, which have none yet
+func GetSlug(item *models.Item) string {
+	if item.PostName != "" {
+		return item.PostName
 	}
-
-	// Default to "pro" (positive, tips, benefits)
-	return "pro"
-}
-
-// min returns the minimum of two integers
-func min(a, b int) int {
-	if a < b {
-		return a
-	}
-	return b
+	return GenerateSlug(item.Title)
 }
 
 // GetFeaturedImageID gets the featured image ID from post meta
@@ -238,16 +235,6 @@ func GetFeaturedImageID(item *models.Item) string {
 	return GetPostMeta(item, "_thumbnail_id")
 }
 
-// FindAttachmentByID finds an attachment item by ID
-func FindAttachmentByID(items []models.Item, id string) *models.Item {
-	for i := range items {
-		if items[i].PostType == "attachment" && fmt.Sprintf("%d", items[i].PostID) == id {
-			return &items[i]
-		}
-	}
-	return nil
-}
-
 // SanitizeFilename removes invalid characters from filenames
 func SanitizeFilename(name string) string {
 	// Remove or replace invalid filename characters
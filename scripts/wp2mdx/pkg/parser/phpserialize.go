@@ -0,0 +1,447 @@
+package parser
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aequinox/gesundes-leben/wp2mdx/pkg/models"
+)
+
+// Unserialize decodes a value produced by PHP's serialize(). Arrays with
+// consecutive integer keys starting at 0 become []interface{}, all other
+// arrays and objects become map[string]interface{}. Scalars decode to nil,
+// bool, int64, float64 and string.
+//
+// String lengths are byte counts, as in PHP, so multibyte UTF-8 text decodes
+// unchanged. Values whose lengths were broken by a charset conversion of the
+// database are recovered by taking the string up to its closing `";`.
+func Unserialize(data string) (interface{}, error) {
+	d := &phpDecoder{data: data}
+	value, err := d.value()
+	if err != nil {
+		return nil, err
+	}
+	if d.pos != len(d.data) {
+		return nil, fmt.Errorf("unexpected data after serialized value at offset %d", d.pos)
+	}
+	return value, nil
+}
+
+// IsSerialized reports whether a postmeta value looks like PHP serialized
+// data, mirroring WordPress' is_serialized()
+func IsSerialized(data string) bool {
+	data = strings.TrimSpace(data)
+	if data == "N;" {
+		return true
+	}
+	if len(data) < 4 || data[1] != ':' {
+		return false
+	}
+
+	switch data[0] {
+	case 'a', 'O':
+		return strings.HasSuffix(data, "}")
+	case 's':
+		return strings.HasSuffix(data, `";`)
+	case 'b', 'i', 'd':
+		return strings.HasSuffix(data, ";")
+	}
+	return false
+}
+
+// GetPostMetaDecoded retrieves a post meta value and decodes it if it is PHP
+// serialized. Plain values are returned as strings; missing keys as nil.
+func GetPostMetaDecoded(item *models.Item, key string) (interface{}, error) {
+	for _, meta := range item.PostMeta {
+		if meta.Key != key {
+			continue
+		}
+		if !IsSerialized(meta.Value) {
+			return meta.Value, nil
+		}
+		value, err := Unserialize(strings.TrimSpace(meta.Value))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode post meta %s: %w", key, err)
+		}
+		return value, nil
+	}
+	return nil, nil
+}
+
+// GetPostMetaMap retrieves a serialized post meta array as a map. Lists are
+// keyed by their index.
+func GetPostMetaMap(item *models.Item, key string) map[string]interface{} {
+	value, err := GetPostMetaDecoded(item, key)
+	if err != nil {
+		return nil
+	}
+	return PHPMap(value)
+}
+
+// GetPostMetaList retrieves a serialized post meta array as a list
+func GetPostMetaList(item *models.Item, key string) []interface{} {
+	value, err := GetPostMetaDecoded(item, key)
+	if err != nil {
+		return nil
+	}
+	return PHPList(value)
+}
+
+// GetPostMetaStrings retrieves a serialized post meta array as a list of
+// strings, e.g. ACF checkbox fields or plugin keyword lists. A plain value
+// is returned as a single-element list.
+func GetPostMetaStrings(item *models.Item, key string) []string {
+	value, err := GetPostMetaDecoded(item, key)
+	if err != nil || value == nil {
+		return nil
+	}
+
+	if s, ok := value.(string); ok {
+		if s == "" {
+			return nil
+		}
+		return []string{s}
+	}
+
+	var result []string
+	for _, v := range PHPList(value) {
+		if s := PHPString(v); s != "" {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+// PHPLookup walks nested arrays along keys, e.g.
+// PHPLookup(meta, "sizes", "medium", "file"). It returns nil if a key is
+// missing.
+func PHPLookup(value interface{}, keys ...string) interface{} {
+	for _, key := range keys {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			value = v[key]
+		case []interface{}:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil
+			}
+			value = v[i]
+		default:
+			return nil
+		}
+	}
+	return value
+}
+
+// PHPString converts a decoded scalar to a string the way PHP would
+func PHPString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		if v {
+			return "1"
+		}
+	}
+	return ""
+}
+
+// PHPInt converts a decoded scalar to an int. Numeric strings are accepted,
+// since WordPress often stores numbers as strings.
+func PHPInt(value interface{}) (int, bool) {
+	switch v := value.(type) {
+	case int64:
+		return int(v), true
+	case float64:
+		return int(v), true
+	case bool:
+		if v {
+			return 1, true
+		}
+		return 0, true
+	case string:
+		i, err := strconv.Atoi(strings.TrimSpace(v))
+		return i, err == nil
+	}
+	return 0, false
+}
+
+// PHPBool converts a decoded scalar to a bool using PHP's truthiness rules
+func PHPBool(value interface{}) bool {
+	switch v := value.(type) {
+	case bool:
+		return v
+	case int64:
+		return v != 0
+	case float64:
+		return v != 0
+	case string:
+		return v != "" && v != "0"
+	case []interface{}:
+		return len(v) > 0
+	case map[string]interface{}:
+		return len(v) > 0
+	}
+	return false
+}
+
+// PHPMap returns a decoded array as a map. Lists are keyed by their index.
+func PHPMap(value interface{}) map[string]interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return v
+	case []interface{}:
+		m := make(map[string]interface{}, len(v))
+		for i, item := range v {
+			m[strconv.Itoa(i)] = item
+		}
+		return m
+	}
+	return nil
+}
+
+// PHPList returns the values of a decoded array. Maps with non-sequential
+// keys are returned in ascending key order.
+func PHPList(value interface{}) []interface{} {
+	switch v := value.(type) {
+	case []interface{}:
+		return v
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sortPHPKeys(keys)
+		list := make([]interface{}, len(keys))
+		for i, key := range keys {
+			list[i] = v[key]
+		}
+		return list
+	}
+	return nil
+}
+
+// sortPHPKeys sorts array keys numerically where possible, then as strings
+func sortPHPKeys(keys []string) {
+	sort.Slice(keys, func(i, j int) bool {
+		a, aErr := strconv.Atoi(keys[i])
+		b, bErr := strconv.Atoi(keys[j])
+		switch {
+		case aErr == nil && bErr == nil:
+			return a < b
+		case aErr == nil || bErr == nil:
+			return aErr == nil
+		}
+		return keys[i] < keys[j]
+	})
+}
+
+// phpDecoder is a cursor over serialized data
+type phpDecoder struct {
+	data string
+	pos  int
+}
+
+// value decodes the value at the cursor
+func (d *phpDecoder) value() (interface{}, error) {
+	if d.pos+1 >= len(d.data) {
+		return nil, fmt.Errorf("unexpected end of serialized data")
+	}
+
+	kind := d.data[d.pos]
+	if kind == 'N' {
+		if err := d.expect("N;"); err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	if err := d.expect(string(kind) + ":"); err != nil {
+		return nil, err
+	}
+
+	switch kind {
+	case 'b':
+		token, err := d.until(';')
+		if err != nil {
+			return nil, err
+		}
+		return token == "1", nil
+
+	case 'i':
+		token, err := d.until(';')
+		if err != nil {
+			return nil, err
+		}
+		i, err := strconv.ParseInt(token, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid integer %q at offset %d", token, d.pos)
+		}
+		return i, nil
+
+	case 'd':
+		token, err := d.until(';')
+		if err != nil {
+			return nil, err
+		}
+		switch token {
+		case "INF":
+			token = "+Inf"
+		case "-INF":
+			token = "-Inf"
+		}
+		f, err := strconv.ParseFloat(token, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid float %q at offset %d", token, d.pos)
+		}
+		return f, nil
+
+	case 's':
+		s, err := d.string()
+		if err != nil {
+			return nil, err
+		}
+		return s, d.expect(";")
+
+	case 'a':
+		return d.array()
+
+	case 'O':
+		// Objects are decoded like arrays; the class name is dropped
+		if _, err := d.string(); err != nil {
+			return nil, err
+		}
+		if err := d.expect(":"); err != nil {
+			return nil, err
+		}
+		return d.array()
+	}
+
+	return nil, fmt.Errorf("unsupported serialized type %q at offset %d", kind, d.pos-2)
+}
+
+// string decodes a length-prefixed, quoted string: `12:"..."`
+func (d *phpDecoder) string() (string, error) {
+	token, err := d.until(':')
+	if err != nil {
+		return "", err
+	}
+	length, err := strconv.Atoi(token)
+	if err != nil || length < 0 {
+		return "", fmt.Errorf("invalid string length %q at offset %d", token, d.pos)
+	}
+	if err := d.expect(`"`); err != nil {
+		return "", err
+	}
+	if length > len(d.data)-d.pos {
+		return "", fmt.Errorf("string length %d exceeds data at offset %d", length, d.pos)
+	}
+
+	start := d.pos
+	end := start + length
+	if end+1 < len(d.data) && d.data[end] == '"' && (d.data[end+1] == ';' || d.data[end+1] == ':') {
+		d.pos = end + 1
+		return d.data[start:end], nil
+	}
+
+	// The byte length does not match, most likely because the text was
+	// re-encoded after serializing; fall back to the closing quote
+	closing := strings.Index(d.data[start:], `";`)
+	if closing == -1 {
+		return "", fmt.Errorf("unterminated string at offset %d", start)
+	}
+	d.pos = start + closing + 1
+	return d.data[start : start+closing], nil
+}
+
+// array decodes the element count and body of an array: `2:{...}`
+func (d *phpDecoder) array() (interface{}, error) {
+	token, err := d.until(':')
+	if err != nil {
+		return nil, err
+	}
+	count, err := strconv.Atoi(token)
+	if err != nil || count < 0 {
+		return nil, fmt.Errorf("invalid array length %q at offset %d", token, d.pos)
+	}
+	if err := d.expect("{"); err != nil {
+		return nil, err
+	}
+	// Every element takes at least a byte, so longer counts are malformed
+	if count > len(d.data)-d.pos {
+		return nil, fmt.Errorf("array length %d exceeds data at offset %d", count, d.pos)
+	}
+
+	var keys []string
+	values := []interface{}{}
+	sequential := true
+
+	for i := 0; i < count; i++ {
+		key, err := d.value()
+		if err != nil {
+			return nil, err
+		}
+		switch k := key.(type) {
+		case int64:
+			sequential = sequential && k == int64(i)
+		case string:
+			// Private and protected object properties are prefixed with
+			// "\0Class\0" and "\0*\0"
+			if strings.HasPrefix(k, "\x00") {
+				if idx := strings.LastIndexByte(k, 0); idx != -1 {
+					key = k[idx+1:]
+				}
+			}
+			sequential = false
+		default:
+			return nil, fmt.Errorf("invalid array key at offset %d", d.pos)
+		}
+
+		value, err := d.value()
+		if err != nil {
+			return nil, err
+		}
+
+		keys = append(keys, PHPString(key))
+		values = append(values, value)
+	}
+
+	if err := d.expect("}"); err != nil {
+		return nil, err
+	}
+
+	if sequential {
+		return values, nil
+	}
+
+	m := make(map[string]interface{}, len(keys))
+	for i, key := range keys {
+		m[key] = values[i]
+	}
+	return m, nil
+}
+
+// until returns the text up to the next sep and moves past it
+func (d *phpDecoder) until(sep byte) (string, error) {
+	idx := strings.IndexByte(d.data[d.pos:], sep)
+	if idx == -1 {
+		return "", fmt.Errorf("expected %q after offset %d", sep, d.pos)
+	}
+	token := d.data[d.pos : d.pos+idx]
+	d.pos += idx + 1
+	return token, nil
+}
+
+// expect consumes s or fails
+func (d *phpDecoder) expect(s string) error {
+	if !strings.HasPrefix(d.data[d.pos:], s) {
+		return fmt.Errorf("expected %q at offset %d", s, d.pos)
+	}
+	d.pos += len(s)
+	return nil
+}
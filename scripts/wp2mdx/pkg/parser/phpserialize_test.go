@@ -0,0 +1,61 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestUnserialize(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want interface{}
+	}{
+		{"null", "N;", nil},
+		{"bool", "b:1;", true},
+		{"int", "i:-42;", int64(-42)},
+		{"float", "d:0.5;", 0.5},
+		{"string", `s:6:"Größe";`, "Größe"},
+		{"list", `a:2:{i:0;s:1:"a";i:1;s:1:"b";}`, []interface{}{"a", "b"}},
+		{"map", `a:1:{s:3:"alt";s:4:"Bild";}`, map[string]interface{}{"alt": "Bild"}},
+		{"empty array", "a:0:{}", []interface{}{}},
+		{"broken length", `s:4:"Größe";`, "Größe"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Unserialize(tt.data)
+			if err != nil {
+				t.Fatalf("Unserialize(%q) error = %v", tt.data, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Unserialize(%q) = %#v, want %#v", tt.data, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnserializeMalformed(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"huge array length", "a:9999999999999:{}"},
+		{"array length beyond data", `a:5:{i:0;N;}`},
+		{"huge string length", `s:9223372036854775807:"x";`},
+		{"string length beyond data", `s:50:"x";`},
+		{"negative string length", `s:-1:"x";`},
+		{"unterminated string", `s:1:"x`},
+		{"truncated array", `a:1:{i:0;`},
+		{"trailing data", "i:1;i:2;"},
+		{"unknown type", "x:1;"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, err := Unserialize(tt.data); err == nil {
+				t.Errorf("Unserialize(%q) = %#v, want error", tt.data, got)
+			}
+		})
+	}
+}
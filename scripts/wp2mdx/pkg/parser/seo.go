@@ -0,0 +1,112 @@
+package parser
+
+import (
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/aequinox/gesundes-leben/wp2mdx/pkg/models"
+)
+
+// seoPlugin lists the postmeta keys one SEO plugin stores its data under
+type seoPlugin struct {
+	name        string
+	title       string
+	description string
+	keywords    string
+	canonical   string
+	ogImage     string
+	ogImageID   string
+}
+
+// seoPlugins are tried in order; the first plugin with any data wins
+var seoPlugins = []seoPlugin{
+	{
+		name:        "yoast",
+		title:       "_yoast_wpseo_title",
+		description: "_yoast_wpseo_metadesc",
+		keywords:    "_yoast_wpseo_focuskw",
+		canonical:   "_yoast_wpseo_canonical",
+		ogImage:     "_yoast_wpseo_opengraph-image",
+		ogImageID:   "_yoast_wpseo_opengraph-image-id",
+	},
+	{
+		name:        "rankmath",
+		title:       "rank_math_title",
+		description: "rank_math_description",
+		keywords:    "rank_math_focus_keyword",
+		canonical:   "rank_math_canonical_url",
+		ogImage:     "rank_math_facebook_image",
+		ogImageID:   "rank_math_facebook_image_id",
+	},
+}
+
+// seoVariableRe matches Yoast (%%title%%) and Rank Math (%title%) template
+// variables, including arguments such as %customfield(name)%
+var seoVariableRe = regexp.MustCompile(`%%?[a-z_]+(?:\([^)]*\))?%%?`)
+
+// GetSEOMeta extracts the Yoast SEO or Rank Math metadata of an item.
+// Template variables for the title, excerpt and separator are expanded; all
+// others, such as the site name, are removed.
+func GetSEOMeta(item *models.Item) models.SEOMeta {
+	for _, plugin := range seoPlugins {
+		meta := models.SEOMeta{
+			Title:        expandSEOVariables(GetPostMeta(item, plugin.title), item),
+			Description:  expandSEOVariables(GetPostMeta(item, plugin.description), item),
+			Keywords:     splitKeywords(GetPostMeta(item, plugin.keywords)),
+			CanonicalURL: strings.TrimSpace(GetPostMeta(item, plugin.canonical)),
+			OGImageURL:   strings.TrimSpace(GetPostMeta(item, plugin.ogImage)),
+		}
+		meta.OGImageID, _ = strconv.Atoi(strings.TrimSpace(GetPostMeta(item, plugin.ogImageID)))
+
+		if meta.Title != "" || meta.Description != "" || len(meta.Keywords) > 0 ||
+			meta.CanonicalURL != "" || meta.OGImageURL != "" || meta.OGImageID != 0 {
+			meta.Source = plugin.name
+			return meta
+		}
+	}
+
+	return models.SEOMeta{}
+}
+
+// expandSEOVariables replaces SEO plugin template variables in a title or
+// description and normalizes whitespace
+func expandSEOVariables(text string, item *models.Item) string {
+	text = seoVariableRe.ReplaceAllStringFunc(text, func(variable string) string {
+		switch strings.Trim(variable, "%") {
+		case "title":
+			return item.Title
+		case "excerpt", "excerpt_only":
+			return item.Excerpt
+		case "sep":
+			return "–"
+		}
+		return ""
+	})
+
+	text = html.UnescapeString(text)
+	text = strings.Join(strings.Fields(text), " ")
+
+	// Removing separators can leave dangling punctuation at either end
+	return strings.Trim(text, " -–—|·•")
+}
+
+// splitKeywords splits a comma-separated focus keyword list, dropping
+// duplicates and empty entries
+func splitKeywords(value string) []string {
+	var keywords []string
+	seen := make(map[string]bool)
+
+	for _, keyword := range strings.Split(value, ",") {
+		keyword = strings.TrimSpace(html.UnescapeString(keyword))
+		key := strings.ToLower(keyword)
+		if keyword == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		keywords = append(keywords, keyword)
+	}
+
+	return keywords
+}
@@ -0,0 +1,128 @@
+package parser
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/aequinox/gesundes-leben/wp2mdx/pkg/models"
+)
+
+// ItemHandler is called for every item read from the export. Returning an
+// error stops the stream.
+type ItemHandler func(item *models.Item) error
+
+// Stream parses the WordPress XML file token by token and hands each item to
+// fn as soon as it has been decoded, so the whole export is never held in
+// memory. The returned channel carries the site metadata and authors; its
+// Items slice is left empty.
+func (p *Parser) Stream(fn ItemHandler) (*models.Channel, error) {
+	file, err := os.Open(p.filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open XML file: %w", err)
+	}
+	defer file.Close()
+
+	return StreamReader(bufio.NewReader(file), fn)
+}
+
+// StreamReader decodes a WordPress export from r, calling fn for every item
+func StreamReader(r io.Reader, fn ItemHandler) (*models.Channel, error) {
+	decoder := xml.NewDecoder(r)
+	channel := &models.Channel{}
+	inChannel := false
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse XML: %w", err)
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		switch {
+		case start.Name.Local == "rss":
+			// Descend into the document root
+		case start.Name.Local == "channel":
+			inChannel = true
+		case !inChannel:
+			if err := decoder.Skip(); err != nil {
+				return nil, fmt.Errorf("failed to parse XML: %w", err)
+			}
+		case start.Name.Local == "item":
+			var item models.Item
+			if err := decoder.DecodeElement(&item, &start); err != nil {
+				return nil, fmt.Errorf("failed to decode item: %w", err)
+			}
+			if err := fn(&item); err != nil {
+				return nil, err
+			}
+		case start.Name.Local == "author":
+			var author models.Author
+			if err := decoder.DecodeElement(&author, &start); err != nil {
+				return nil, fmt.Errorf("failed to decode author: %w", err)
+			}
+			channel.Authors = append(channel.Authors, author)
+		case start.Name.Local == "category" || start.Name.Local == "tag" || start.Name.Local == "term":
+			var term wpTerm
+			if err := decoder.DecodeElement(&term, &start); err != nil {
+				return nil, fmt.Errorf("failed to decode term: %w", err)
+			}
+			channel.Terms = append(channel.Terms, term.toTerm(start.Name.Local))
+		case start.Name.Local == "title":
+			if err := decoder.DecodeElement(&channel.Title, &start); err != nil {
+				return nil, fmt.Errorf("failed to parse XML: %w", err)
+			}
+		case start.Name.Local == "link":
+			if err := decoder.DecodeElement(&channel.Link, &start); err != nil {
+				return nil, fmt.Errorf("failed to parse XML: %w", err)
+			}
+		case start.Name.Local == "description":
+			if err := decoder.DecodeElement(&channel.Description, &start); err != nil {
+				return nil, fmt.Errorf("failed to parse XML: %w", err)
+			}
+		case start.Name.Local == "language":
+			if err := decoder.DecodeElement(&channel.Language, &start); err != nil {
+				return nil, fmt.Errorf("failed to parse XML: %w", err)
+			}
+		default:
+			// Skip channel elements we don't model (image, terms, generator, ...)
+			if err := decoder.Skip(); err != nil {
+				return nil, fmt.Errorf("failed to parse XML: %w", err)
+			}
+		}
+	}
+
+	return channel, nil
+}
+
+// wpTerm holds the fields of the wp:category, wp:tag and wp:term elements
+type wpTerm struct {
+	CategoryNicename string `xml:"category_nicename"`
+	CategoryName     string `xml:"cat_name"`
+	TagSlug          string `xml:"tag_slug"`
+	TagName          string `xml:"tag_name"`
+	TermTaxonomy     string `xml:"term_taxonomy"`
+	TermSlug         string `xml:"term_slug"`
+	TermName         string `xml:"term_name"`
+}
+
+// toTerm converts a term element with the given local name
+func (t wpTerm) toTerm(element string) models.Term {
+	switch element {
+	case "category":
+		return models.Term{Taxonomy: "category", Slug: t.CategoryNicename, Name: t.CategoryName}
+	case "tag":
+		return models.Term{Taxonomy: "post_tag", Slug: t.TagSlug, Name: t.TagName}
+	default:
+		return models.Term{Taxonomy: t.TermTaxonomy, Slug: t.TermSlug, Name: t.TermName}
+	}
+}
@@ -0,0 +1,176 @@
+package redirects
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// format renders redirects for one hosting platform
+type format struct {
+	filename string
+	render   func(redirects []Redirect) (string, error)
+}
+
+// formats maps the supported format names to their renderers
+var formats = map[string]format{
+	"netlify": {"_redirects", renderNetlify},
+	"vercel":  {"vercel.json", renderVercel},
+	"nginx":   {"redirects.nginx.conf", renderNginx},
+	"apache":  {".htaccess", renderApache},
+	"astro":   {"redirects.mjs", renderAstro},
+}
+
+// Formats lists the supported format names
+var Formats = []string{"netlify", "vercel", "nginx", "apache", "astro"}
+
+// Filename returns the file a format is written to
+func Filename(name string) (string, error) {
+	f, ok := formats[name]
+	if !ok {
+		return "", fmt.Errorf("unknown redirect format %q (supported: %s)", name, strings.Join(Formats, ", "))
+	}
+	return f.filename, nil
+}
+
+// Render renders redirects in the named format
+func Render(name string, redirects []Redirect) (string, error) {
+	f, ok := formats[name]
+	if !ok {
+		return "", fmt.Errorf("unknown redirect format %q (supported: %s)", name, strings.Join(Formats, ", "))
+	}
+	return f.render(redirects)
+}
+
+// Write renders redirects in each named format into dir and returns the
+// paths of the files written
+func Write(dir string, names []string, redirects []Redirect) ([]string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create redirects directory: %w", err)
+	}
+
+	var written []string
+	for _, name := range names {
+		content, err := Render(name, redirects)
+		if err != nil {
+			return written, err
+		}
+		filename := filepath.Join(dir, formats[name].filename)
+		if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
+			return written, fmt.Errorf("failed to write redirects: %w", err)
+		}
+		written = append(written, filename)
+	}
+
+	return written, nil
+}
+
+// renderNetlify writes a Netlify _redirects file, which also serves
+// Cloudflare Pages
+func renderNetlify(redirects []Redirect) (string, error) {
+	var sb strings.Builder
+	sb.WriteString("# WordPress URLs -> Astro routes\n")
+	for _, r := range redirects {
+		fmt.Fprintf(&sb, "%s %s 301\n", r.From, r.To)
+	}
+	return sb.String(), nil
+}
+
+// renderVercel writes the redirects section of a vercel.json
+func renderVercel(redirects []Redirect) (string, error) {
+	type vercelRedirect struct {
+		Source      string `json:"source"`
+		Destination string `json:"destination"`
+		Permanent   bool   `json:"permanent"`
+	}
+	config := struct {
+		Redirects []vercelRedirect `json:"redirects"`
+	}{Redirects: make([]vercelRedirect, 0, len(redirects))}
+
+	for _, r := range redirects {
+		config.Redirects = append(config.Redirects, vercelRedirect{
+			Source:      vercelEscaper.Replace(r.From),
+			Destination: r.To,
+			Permanent:   true,
+		})
+	}
+
+	data, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to render vercel redirects: %w", err)
+	}
+	return string(data) + "\n", nil
+}
+
+// vercelEscaper escapes the characters Vercel reads as path patterns
+var vercelEscaper = strings.NewReplacer(
+	":", `\:`, "(", `\(`, ")", `\)`, "*", `\*`, "+", `\+`, "?", `\?`,
+)
+
+// renderNginx writes a map from request paths to their new location. nginx
+// matches $uri decoded, so both slash variants of each path are listed.
+func renderNginx(redirects []Redirect) (string, error) {
+	var sb strings.Builder
+	sb.WriteString("# WordPress URLs -> Astro routes\n")
+	sb.WriteString("# Include in the http block and add to the server block:\n")
+	sb.WriteString("#   if ($redirect_uri) { return 301 $redirect_uri; }\n")
+	sb.WriteString("map $uri $redirect_uri {\n")
+	sb.WriteString("    default \"\";\n")
+	for _, r := range redirects {
+		from := strings.TrimSuffix(decodePath(r.From), "/")
+		fmt.Fprintf(&sb, "    %s %s;\n", nginxQuote(from), nginxQuote(r.To))
+		fmt.Fprintf(&sb, "    %s %s;\n", nginxQuote(from+"/"), nginxQuote(r.To))
+	}
+	sb.WriteString("}\n")
+	return sb.String(), nil
+}
+
+// nginxQuote quotes a map key or value
+func nginxQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// renderApache writes mod_alias rules for an .htaccess file. Each rule
+// matches the path exactly, with or without the trailing slash.
+func renderApache(redirects []Redirect) (string, error) {
+	var sb strings.Builder
+	sb.WriteString("# WordPress URLs -> Astro routes\n")
+	for _, r := range redirects {
+		from := regexp.QuoteMeta(strings.TrimSuffix(decodePath(r.From), "/"))
+		fmt.Fprintf(&sb, "RedirectMatch 301 \"^%s/?$\" \"%s\"\n", strings.ReplaceAll(from, " ", `\s`), r.To)
+	}
+	return sb.String(), nil
+}
+
+// renderAstro writes a module exporting the redirects object of
+// astro.config, e.g. `redirects: (await import("./redirects.mjs")).default`
+func renderAstro(redirects []Redirect) (string, error) {
+	var sb strings.Builder
+	sb.WriteString("// WordPress URLs -> Astro routes\n")
+	sb.WriteString("export default {\n")
+	for _, r := range redirects {
+		from, err := json.Marshal(r.From)
+		if err != nil {
+			return "", fmt.Errorf("failed to render astro redirects: %w", err)
+		}
+		to, err := json.Marshal(r.To)
+		if err != nil {
+			return "", fmt.Errorf("failed to render astro redirects: %w", err)
+		}
+		fmt.Fprintf(&sb, "  %s: { status: 301, destination: %s },\n", from, to)
+	}
+	sb.WriteString("};\n")
+	return sb.String(), nil
+}
+
+// decodePath percent-decodes a path for servers that match decoded paths
+func decodePath(path string) string {
+	if decoded, err := url.PathUnescape(path); err == nil {
+		return decoded
+	}
+	return path
+}
@@ -0,0 +1,134 @@
+// Package redirects builds the 301 redirects from WordPress URLs to the
+// routes of the Astro site and writes them for common hosting platforms.
+package redirects
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/aequinox/gesundes-leben/wp2mdx/pkg/links"
+	"github.com/aequinox/gesundes-leben/wp2mdx/pkg/models"
+)
+
+// Route prefixes of the archive pages on the Astro site
+const (
+	CategoriesPath = "/categories/"
+	TagsPath       = "/tags/"
+)
+
+// Redirect is a permanent redirect from an old path to a new one. Source
+// names what the old path was: post, page, attachment, category or tag.
+type Redirect struct {
+	From   string
+	To     string
+	Source string
+}
+
+// Builder collects the URLs of an export
+type Builder struct {
+	index       *links.Index
+	mapCategory func(name string) string
+	categories  map[string]string // WordPress slug → name
+	tags        map[string]string
+}
+
+// New creates a builder for the items in index. mapCategory maps a
+// WordPress category name to the blog category it was converted to.
+func New(index *links.Index, mapCategory func(name string) string) *Builder {
+	return &Builder{
+		index:       index,
+		mapCategory: mapCategory,
+		categories:  make(map[string]string),
+		tags:        make(map[string]string),
+	}
+}
+
+// AddItem collects the category and tag archives a converted item appears
+// in. Only these terms end up in frontmatter, so archives of other terms
+// have no page on the site. Other taxonomies are ignored.
+func (b *Builder) AddItem(item *models.Item) {
+	for _, cat := range item.Categories {
+		if cat.Nicename == "" || cat.Value == "" {
+			continue
+		}
+		switch cat.Domain {
+		case "category":
+			b.categories[cat.Nicename] = cat.Value
+		case "post_tag":
+			b.tags[cat.Nicename] = cat.Value
+		}
+	}
+}
+
+// Build returns the redirects sorted by old path, and notes on old URLs
+// that have no new route
+func (b *Builder) Build() ([]Redirect, []string) {
+	var redirects []Redirect
+	var notes []string
+	seen := make(map[string]bool)
+
+	add := func(from, to, source string) {
+		if from == "" || from == to || seen[from] {
+			return
+		}
+		seen[from] = true
+		redirects = append(redirects, Redirect{From: from, To: to, Source: source})
+	}
+
+	for _, t := range b.index.Targets() {
+		switch {
+		case t.Type == "attachment":
+			parent := b.index.ByID(t.Parent)
+			if parent == nil || !parent.Converted || !parent.Published() {
+				continue
+			}
+			add(oldPath(t.Link), parent.Route(), "attachment")
+
+		case t.Converted && t.Published():
+			to := t.Route()
+			add(oldPath(t.Link), to, t.Type)
+			// Bare post_name URLs also reach the post in WordPress
+			if t.Slug != "" {
+				add("/"+t.Slug+"/", to, t.Type)
+			}
+
+		case t.Published() && (t.Type == "post" || t.Type == "page"):
+			notes = append(notes, fmt.Sprintf("%s %d %q is not converted: %s", t.Type, t.ID, t.Title, t.Link))
+		}
+	}
+
+	for _, slug := range sortedKeys(b.categories) {
+		category := b.mapCategory(strings.ToLower(strings.TrimSpace(b.categories[slug])))
+		add("/category/"+slug+"/", CategoriesPath+links.Slugify(category)+"/", "category")
+	}
+	for _, slug := range sortedKeys(b.tags) {
+		add("/tag/"+slug+"/", TagsPath+links.Slugify(b.tags[slug])+"/", "tag")
+	}
+
+	sort.SliceStable(redirects, func(i, j int) bool {
+		return redirects[i].From < redirects[j].From
+	})
+	return redirects, notes
+}
+
+// oldPath returns the path of a WordPress URL. URLs that only work through
+// a query, such as "?p=123", yield "".
+func oldPath(link string) string {
+	u, err := url.Parse(strings.TrimSpace(link))
+	if err != nil || u.RawQuery != "" || u.Path == "" || u.Path == "/" {
+		return ""
+	}
+	return u.EscapedPath()
+}
+
+// sortedKeys returns the keys of a map in ascending order
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,58 @@
+package redirects
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aequinox/gesundes-leben/wp2mdx/pkg/links"
+	"github.com/aequinox/gesundes-leben/wp2mdx/pkg/models"
+)
+
+func TestBuild(t *testing.T) {
+	index := links.NewIndex()
+	index.SetSiteURL("https://example.com")
+	builder := New(index, func(name string) string {
+		if name == "nutrition" {
+			return "Ernährung"
+		}
+		return name
+	})
+
+	post := &models.Item{
+		PostID:   1,
+		Title:    "Darm & Hirn",
+		Link:     "https://example.com/darm-hirn-achse/",
+		PostName: "darm-hirn-achse",
+		PostType: "post",
+		Status:   "publish",
+		Categories: []models.Category{
+			{Domain: "category", Nicename: "nutrition", Value: "Nutrition"},
+			{Domain: "post_tag", Nicename: "darm", Value: "Darm"},
+		},
+	}
+	index.Add(post).Converted = true
+	builder.AddItem(post)
+
+	// Tags of items that are not converted have no archive on the site
+	draft := &models.Item{
+		PostID:     2,
+		Title:      "Entwurf",
+		PostType:   "post",
+		Status:     "draft",
+		Categories: []models.Category{{Domain: "post_tag", Nicename: "entwurf", Value: "Entwurf"}},
+	}
+	index.Add(draft)
+
+	got, notes := builder.Build()
+	want := []Redirect{
+		{From: "/category/nutrition/", To: "/categories/ernaehrung/", Source: "category"},
+		{From: "/darm-hirn-achse/", To: "/posts/darm-und-hirn/", Source: "post"},
+		{From: "/tag/darm/", To: "/tags/darm/", Source: "tag"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Build() = %+v, want %+v", got, want)
+	}
+	if len(notes) != 0 {
+		t.Errorf("Build() notes = %v, want none", notes)
+	}
+}
@@ -0,0 +1,376 @@
+package references
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// sectionHeading matches the headings of references sections, e.g.
+// "Quellen:" or "Literaturverzeichnis"
+var sectionHeading = regexp.MustCompile(`(?i)^(quellen(angaben|verzeichnis)?|literatur(angaben|verzeichnis)?|referenzen|references|sources|studien)\s*:?$`)
+
+// sectionID matches the anchors authors give references sections
+var sectionID = regexp.MustCompile(`(?i)^(quellen|literatur|referenzen|references|sources)\b`)
+
+var (
+	doiPattern     = regexp.MustCompile(`10\.\d{4,9}/[^\s"<>]+`)
+	pmidURLPattern = regexp.MustCompile(`pubmed\.ncbi\.nlm\.nih\.gov/(\d+)`)
+	pmidPattern    = regexp.MustCompile(`PMID:?\s*(\d+)`)
+	urlPattern     = regexp.MustCompile(`https?://[^\s"<>]+`)
+
+	// apaEntry matches "Authors (Year). Rest"
+	apaEntry = regexp.MustCompile(`^(.+?)\s*\((\d{4})[a-z]?(?:,[^)]*)?\)\.?\s*(.+)$`)
+	// apaSource matches ", 15(14), 10821" after the journal name
+	apaSource = regexp.MustCompile(`^[\s,]*(\d+)?\s*(?:\(([^)]+)\))?[\s,]*([A-Za-z]?\d+(?:\s*[-–—]\s*[A-Za-z]?\d+)?)?`)
+	// vancouverEntry matches "Authors. Title. Journal. 2019;12(3):45-67"
+	vancouverEntry = regexp.MustCompile(`^([^.]+)\.\s+(.+?[.?!])\s+([^.;]+?)\.?\s+((?:19|20)\d{2})[^;:.]*(?:;\s*(\d+)?(?:\(([^)]+)\))?(?::\s*([A-Za-z]?\d+(?:[-–—][A-Za-z]?\d+)?))?)?`)
+	// apaAuthor matches "Surname, I. I." in an APA author list
+	apaAuthor = regexp.MustCompile(`([^,&]+?),\s*((?:\p{Lu}\p{Ll}?\.[\s-]*)+)`)
+	// vancouverAuthor matches "Surname IB" in a Vancouver author list
+	vancouverAuthor = regexp.MustCompile(`^(.+?)\s+(\p{Lu}{1,3})$`)
+)
+
+// Extract parses the entries of the references section in a post's HTML
+// content. It returns the parsed references and a warning for each entry
+// that could not be parsed.
+func Extract(content string) ([]*Reference, []string, error) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(content))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse content: %w", err)
+	}
+
+	var refs []*Reference
+	var warnings []string
+	for _, entry := range findEntries(doc) {
+		text := entryText(entry)
+		if text == "" {
+			continue
+		}
+		ref := parseEntry(entry, text)
+		if ref == nil {
+			warnings = append(warnings, fmt.Sprintf("could not parse reference %q", truncate(text, 80)))
+			continue
+		}
+		refs = append(refs, ref)
+	}
+
+	return refs, warnings, nil
+}
+
+// findEntries returns the list items of the references section, or its
+// paragraphs if the entries are not a list
+func findEntries(doc *goquery.Document) []*goquery.Selection {
+	var section *goquery.Selection
+
+	doc.Find("[id]").EachWithBreak(func(_ int, s *goquery.Selection) bool {
+		if id, _ := s.Attr("id"); sectionID.MatchString(id) {
+			section = s
+			return false
+		}
+		return true
+	})
+	if section != nil {
+		// Anchors on a heading mark the entries that follow it
+		if section.Is("h1, h2, h3, h4, h5, h6") {
+			return followingEntries(section)
+		}
+		return sectionEntries(section)
+	}
+
+	var entries []*goquery.Selection
+	doc.Find("h1, h2, h3, h4, h5, h6, p, summary, .kt-blocks-accordion-title").EachWithBreak(func(_ int, s *goquery.Selection) bool {
+		if !sectionHeading.MatchString(strings.TrimSpace(s.Text())) {
+			return true
+		}
+		// Collapsible sections hold the entries below their title
+		if pane := s.Closest(".wp-block-kadence-pane, details"); pane.Length() > 0 {
+			entries = sectionEntries(pane)
+			return false
+		}
+		entries = followingEntries(s)
+		return false
+	})
+
+	return entries
+}
+
+// followingEntries returns the entries that follow a heading up to the
+// next one
+func followingEntries(heading *goquery.Selection) []*goquery.Selection {
+	block := heading
+	for block.Parent().Length() > 0 && !block.Parent().Is("body") {
+		block = block.Parent()
+	}
+	return sectionEntries(block.NextUntil("h1, h2, h3, h4, h5, h6"))
+}
+
+// sectionEntries returns the list items in a section, or its paragraphs
+func sectionEntries(section *goquery.Selection) []*goquery.Selection {
+	items := section.Find("li").AddSelection(section.Filter("li"))
+	if items.Length() == 0 {
+		items = section.Find("p").AddSelection(section.Filter("p"))
+	}
+
+	var entries []*goquery.Selection
+	items.Each(func(_ int, s *goquery.Selection) {
+		entries = append(entries, s)
+	})
+	return entries
+}
+
+// entryText returns the plain text of an entry. Some exports encode
+// entities twice, so the text is unescaped once more.
+func entryText(s *goquery.Selection) string {
+	return strings.Join(strings.Fields(html.UnescapeString(s.Text())), " ")
+}
+
+// parseEntry parses an APA or Vancouver style citation. It returns nil if
+// neither year nor title can be found.
+func parseEntry(entry *goquery.Selection, text string) *Reference {
+	ref := &Reference{}
+
+	var emphasized []string
+	entry.Find("em, i").Each(func(_ int, s *goquery.Selection) {
+		emphasized = append(emphasized, entryText(s))
+	})
+
+	if m := apaEntry.FindStringSubmatch(text); m != nil {
+		ref.Authors = parseAPAAuthors(m[1])
+		ref.Year, _ = strconv.Atoi(m[2])
+		parseAPARest(ref, m[3], emphasized)
+	} else if m := vancouverEntry.FindStringSubmatch(text); m != nil {
+		ref.Authors = parseVancouverAuthors(m[1])
+		ref.Title = strings.TrimSuffix(m[2], ".")
+		ref.Journal = strings.TrimSpace(m[3])
+		ref.Year, _ = strconv.Atoi(m[4])
+		ref.Volume, _ = strconv.Atoi(m[5])
+		ref.Issue = m[6]
+		ref.Pages = normalizePages(m[7])
+	}
+	if ref.Year == 0 || ref.Title == "" {
+		return nil
+	}
+
+	parseIdentifiers(ref, entry, text)
+
+	switch {
+	case ref.Journal != "":
+		ref.Type = "journal"
+	case ref.URL != "":
+		ref.Type = "website"
+	default:
+		ref.Type = "other"
+	}
+
+	return ref
+}
+
+// parseAPARest parses "Title. Journal, 15(14), 10821. https://doi.org/..."
+// The journal and volume are set in italics in APA, which tells the title
+// apart from the journal.
+func parseAPARest(ref *Reference, rest string, emphasized []string) {
+	// Species names in the title may be in italics too; the journal is the
+	// one followed by the volume
+	journal := ""
+	for i, em := range emphasized {
+		if isDigits(em) {
+			continue
+		}
+		journal = em
+		if i+1 < len(emphasized) && isDigits(emphasized[i+1]) {
+			break
+		}
+	}
+
+	idx := -1
+	if journal != "" {
+		idx = journalIndex(rest, journal)
+	}
+	if idx > 0 {
+		ref.Title = trimTitle(rest[:idx])
+		ref.Journal = journal
+
+		source := strings.TrimPrefix(rest[idx+len(journal):], ".")
+		if m := apaSource.FindStringSubmatch(source); m != nil {
+			ref.Volume, _ = strconv.Atoi(m[1])
+			ref.Issue = strings.TrimSpace(m[2])
+			ref.Pages = normalizePages(m[3])
+		}
+		return
+	}
+
+	// Without italics, the title ends at the first sentence break
+	if end := sentenceEnd(rest); end > 0 {
+		ref.Title = trimTitle(rest[:end])
+		return
+	}
+	ref.Title = trimTitle(rest)
+}
+
+// journalIndex returns the position of the journal name in rest: the first
+// occurrence after a sentence break, as the name may also appear in the
+// title or the DOI. It returns -1 if the name does not appear.
+func journalIndex(rest, journal string) int {
+	if journal == "" {
+		return -1
+	}
+	first := -1
+	for offset := 0; ; {
+		idx := strings.Index(rest[offset:], journal)
+		if idx == -1 {
+			return first
+		}
+		idx += offset
+		if first == -1 {
+			first = idx
+		}
+		if before := rest[:idx]; strings.HasSuffix(before, ". ") || strings.HasSuffix(before, "? ") || strings.HasSuffix(before, "! ") {
+			return idx
+		}
+		offset = idx + len(journal)
+	}
+}
+
+// parseIdentifiers sets the DOI, PMID and URL of an entry from its text
+// and links
+func parseIdentifiers(ref *Reference, entry *goquery.Selection, text string) {
+	var hrefs []string
+	entry.Find("a[href]").Each(func(_ int, s *goquery.Selection) {
+		href, _ := s.Attr("href")
+		hrefs = append(hrefs, strings.TrimSpace(html.UnescapeString(href)))
+	})
+
+	for _, source := range append(hrefs, text) {
+		if ref.DOI == "" {
+			if doi := doiPattern.FindString(source); doi != "" {
+				ref.DOI = strings.TrimRight(doi, ".,;)")
+			}
+		}
+		if ref.PMID == "" {
+			if m := pmidURLPattern.FindStringSubmatch(source); m != nil {
+				ref.PMID = m[1]
+			} else if m := pmidPattern.FindStringSubmatch(source); m != nil {
+				ref.PMID = m[1]
+			}
+		}
+	}
+
+	for _, href := range hrefs {
+		if strings.HasPrefix(href, "http://") || strings.HasPrefix(href, "https://") {
+			ref.URL = href
+			return
+		}
+	}
+	if u := urlPattern.FindString(text); u != "" {
+		ref.URL = strings.TrimRight(u, ".,;)")
+	}
+}
+
+// parseAPAAuthors parses "Ghosh, S., Sinha, J. K., & Bhaskar, R." into
+// "Surname, I. I." entries. Authors without initials, such as
+// organizations, are kept as written.
+func parseAPAAuthors(s string) []string {
+	s = strings.ReplaceAll(s, "et al.", "")
+	s = strings.ReplaceAll(s, "…", ",")
+
+	var authors []string
+	for _, m := range apaAuthor.FindAllStringSubmatch(s, -1) {
+		surname := strings.TrimSpace(strings.TrimLeft(m[1], " ,&."))
+		if surname == "" {
+			continue
+		}
+		authors = append(authors, surname+", "+formatInitials(m[2]))
+	}
+	if len(authors) == 0 {
+		if author := strings.Trim(s, " ,.&"); author != "" {
+			authors = append(authors, author)
+		}
+	}
+	return authors
+}
+
+// parseVancouverAuthors parses "Smith J, Doe AB, et al" into "Surname, I. I."
+// entries
+func parseVancouverAuthors(s string) []string {
+	var authors []string
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" || strings.HasPrefix(part, "et al") {
+			continue
+		}
+		if m := vancouverAuthor.FindStringSubmatch(part); m != nil {
+			authors = append(authors, m[1]+", "+formatInitials(m[2]))
+		} else {
+			authors = append(authors, part)
+		}
+	}
+	return authors
+}
+
+// formatInitials formats "J.K." or "JK" as "J. K."; hyphenated initials
+// such as "J.-P." are kept
+func formatInitials(s string) string {
+	var parts []string
+	for _, r := range s {
+		switch {
+		case r == '-' && len(parts) > 0:
+			parts[len(parts)-1] += "-"
+		case r == '.' || r == ' ':
+		default:
+			if n := len(parts); n > 0 && strings.HasSuffix(parts[n-1], "-") {
+				parts[n-1] += string(r) + "."
+			} else if r >= 'a' && r <= 'z' || r == 'ä' || r == 'ö' || r == 'ü' {
+				// Two-letter initials such as "Th."
+				if n > 0 {
+					parts[n-1] = strings.TrimSuffix(parts[n-1], ".") + string(r) + "."
+				}
+			} else {
+				parts = append(parts, string(r)+".")
+			}
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// normalizePages writes page ranges with a hyphen, as in the collection
+func normalizePages(pages string) string {
+	pages = strings.NewReplacer("–", "-", "—", "-", " ", "").Replace(pages)
+	return strings.TrimSpace(pages)
+}
+
+// trimTitle removes the punctuation around a title
+func trimTitle(s string) string {
+	return strings.TrimSpace(strings.TrimRight(strings.TrimSpace(s), ".,"))
+}
+
+// sentenceEnd returns the index of the first sentence break in s, or -1.
+// Question and exclamation marks are part of the title.
+func sentenceEnd(s string) int {
+	for i := 0; i+1 < len(s); i++ {
+		switch s[i] {
+		case '.':
+			if s[i+1] == ' ' {
+				return i
+			}
+		case '?', '!':
+			if s[i+1] == ' ' {
+				return i + 1
+			}
+		}
+	}
+	return -1
+}
+
+// truncate shortens s to at most n runes for messages
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n]) + "…"
+}
@@ -0,0 +1,113 @@
+package references
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestExtract(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    Reference
+	}{
+		{
+			name:    "plain APA",
+			content: `<h2>Quellen</h2><ul><li>Waldo, A. L. (1955). Ascorbic acid in leukemic patients. Blood, 10(5), 500-510.</li></ul>`,
+			want: Reference{
+				Type:    "other",
+				Title:   "Ascorbic acid in leukemic patients",
+				Authors: []string{"Waldo, A. L."},
+				Year:    1955,
+			},
+		},
+		{
+			name:    "italic APA",
+			content: `<h2>Quellen</h2><ul><li>Waldo, A. L. (1955). Ascorbic acid in leukemic patients. <em>Blood</em>, <em>10</em>(5), 500-510.</li></ul>`,
+			want: Reference{
+				Type:    "journal",
+				Title:   "Ascorbic acid in leukemic patients",
+				Authors: []string{"Waldo, A. L."},
+				Year:    1955,
+				Journal: "Blood",
+				Volume:  10,
+				Issue:   "5",
+				Pages:   "500-510",
+			},
+		},
+		{
+			name: "APA with DOI",
+			content: `<h2 id="quellen">Quellen</h2><ol><li>Ghosh, S., Sinha, J. K., &amp; Bhaskar, R. (2023). Microplastics as an emerging threat to the global environment and human health. <em>Sustainability</em>, <em>15</em>(14), 10821. ` +
+				`<a href="https://doi.org/10.3390/su151410821">https://doi.org/10.3390/su151410821</a></li></ol>`,
+			want: Reference{
+				Type:    "journal",
+				Title:   "Microplastics as an emerging threat to the global environment and human health",
+				Authors: []string{"Ghosh, S.", "Sinha, J. K.", "Bhaskar, R."},
+				Year:    2023,
+				Journal: "Sustainability",
+				Volume:  15,
+				Issue:   "14",
+				Pages:   "10821",
+				URL:     "https://doi.org/10.3390/su151410821",
+				DOI:     "10.3390/su151410821",
+			},
+		},
+		{
+			name:    "Vancouver",
+			content: `<h3>Literatur</h3><p>Smith J, Doe AB. Vitamin D and immune function. Nutrients. 2019;12(3):45-67. PMID: 31234567</p>`,
+			want: Reference{
+				Type:    "journal",
+				Title:   "Vitamin D and immune function",
+				Authors: []string{"Smith, J.", "Doe, A. B."},
+				Year:    2019,
+				Journal: "Nutrients",
+				Volume:  12,
+				Issue:   "3",
+				Pages:   "45-67",
+				PMID:    "31234567",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			type result struct {
+				refs     []*Reference
+				warnings []string
+				err      error
+			}
+			done := make(chan result, 1)
+			go func() {
+				refs, warnings, err := Extract(tt.content)
+				done <- result{refs, warnings, err}
+			}()
+
+			var got result
+			select {
+			case got = <-done:
+			case <-time.After(5 * time.Second):
+				t.Fatal("Extract did not return")
+			}
+
+			if got.err != nil {
+				t.Fatalf("Extract() error = %v", got.err)
+			}
+			if len(got.warnings) > 0 {
+				t.Errorf("Extract() warnings = %v", got.warnings)
+			}
+			if len(got.refs) != 1 {
+				t.Fatalf("Extract() returned %d references, want 1", len(got.refs))
+			}
+			if !reflect.DeepEqual(*got.refs[0], tt.want) {
+				t.Errorf("Extract() = %+v, want %+v", *got.refs[0], tt.want)
+			}
+		})
+	}
+}
+
+func TestJournalIndexEmpty(t *testing.T) {
+	if got := journalIndex("Ascorbic acid. Blood, 10(5).", ""); got != -1 {
+		t.Errorf("journalIndex() = %d, want -1", got)
+	}
+}
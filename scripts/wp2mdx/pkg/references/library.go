@@ -0,0 +1,263 @@
+package references
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Library is the references collection. References extracted from posts
+// are linked to existing entries or added as new ones.
+type Library struct {
+	dir       string
+	threshold float64
+	entries   []*Reference
+	existing  int
+	byID      map[string]*Reference
+	byDOI     map[string]*Reference
+	byPMID    map[string]*Reference
+
+	mu        sync.Mutex
+	decisions []Decision
+}
+
+// Decision records what happened to a citation. Action is "link" for
+// citations of an existing entry, "create" for a new entry and "reuse" for
+// a citation of an entry created earlier in the run.
+type Decision struct {
+	ID        string
+	Action    string
+	Reference *Reference
+	// Match is the closest existing entry; for new entries it scored below
+	// the threshold
+	Match Match
+	// Source names where the citation was found, e.g. the post
+	Source string
+}
+
+// LoadLibrary reads the reference entries in dir. A missing directory
+// yields an empty library that never writes entries.
+func LoadLibrary(dir string) (*Library, error) {
+	l := &Library{
+		threshold: DefaultThreshold,
+		byID:      make(map[string]*Reference),
+		byDOI:     make(map[string]*Reference),
+		byPMID:    make(map[string]*Reference),
+	}
+
+	if dir == "" {
+		return l, nil
+	}
+	if _, err := os.Stat(dir); err != nil {
+		if os.IsNotExist(err) {
+			return l, nil
+		}
+		return nil, fmt.Errorf("failed to read references directory: %w", err)
+	}
+	l.dir = dir
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.yaml"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list references: %w", err)
+	}
+
+	sort.Strings(files)
+	for _, file := range files {
+		ref, err := readReference(file)
+		if err != nil {
+			return nil, err
+		}
+		l.add(ref)
+	}
+	l.existing = len(l.entries)
+
+	return l, nil
+}
+
+// readReference reads a reference file; its ID is the file name
+func readReference(filename string) (*Reference, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read reference %s: %w", filename, err)
+	}
+
+	ref := &Reference{}
+	if err := yaml.Unmarshal(data, ref); err != nil {
+		return nil, fmt.Errorf("failed to parse reference %s: %w", filename, err)
+	}
+	ref.ID = strings.TrimSuffix(filepath.Base(filename), filepath.Ext(filename))
+
+	return ref, nil
+}
+
+// add indexes a reference by ID, DOI and PMID
+func (l *Library) add(ref *Reference) {
+	l.entries = append(l.entries, ref)
+	l.byID[ref.ID] = ref
+	if doi := normalizeDOI(ref.DOI); doi != "" {
+		l.byDOI[doi] = ref
+	}
+	if ref.PMID != "" {
+		l.byPMID[ref.PMID] = ref
+	}
+}
+
+// Dir returns the references directory, or "" if it does not exist
+func (l *Library) Dir() string {
+	return l.dir
+}
+
+// SetThreshold sets the score from which a fuzzy match links a citation
+// to an existing entry instead of creating a new one
+func (l *Library) SetThreshold(threshold float64) {
+	l.threshold = threshold
+}
+
+// Threshold returns the score from which fuzzy matches are linked
+func (l *Library) Threshold() float64 {
+	return l.threshold
+}
+
+// Len returns the number of entries loaded from the directory
+func (l *Library) Len() int {
+	return l.existing
+}
+
+// Match returns the closest entry for a reference without adding it
+func (l *Library) Match(ref *Reference) Match {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.match(ref)
+}
+
+// Resolve links a citation to the entry it matches with at least the
+// threshold score. Citations below it are added under a new ID in the
+// site's naming scheme.
+func (l *Library) Resolve(ref *Reference, source string) Decision {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	decision := Decision{Reference: ref, Source: source}
+	m := l.match(ref)
+	switch {
+	case m.Entry != nil && m.Score >= l.threshold:
+		decision.ID = m.Entry.ID
+		decision.Action = "link"
+		if l.isNew(m.Entry) {
+			decision.Action = "reuse"
+		}
+		decision.Match = m
+	default:
+		base := ref.GenerateID()
+		ref.ID = base
+		for n := 2; l.byID[ref.ID] != nil || l.fileExists(ref.ID); n++ {
+			ref.ID = base + "-" + strconv.Itoa(n)
+		}
+		l.add(ref)
+		decision.ID = ref.ID
+		decision.Action = "create"
+		decision.Match = m
+	}
+
+	l.decisions = append(l.decisions, decision)
+	return decision
+}
+
+// isNew reports whether an entry was created by Resolve
+func (l *Library) isNew(ref *Reference) bool {
+	for _, entry := range l.entries[l.existing:] {
+		if entry == ref {
+			return true
+		}
+	}
+	return false
+}
+
+// fileExists reports whether a reference file with the ID exists
+func (l *Library) fileExists(id string) bool {
+	if l.dir == "" {
+		return false
+	}
+	_, err := os.Stat(filepath.Join(l.dir, id+".yaml"))
+	return err == nil
+}
+
+// Decisions returns the decisions made by Resolve in order
+func (l *Library) Decisions() []Decision {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return append([]Decision(nil), l.decisions...)
+}
+
+// Linked returns the IDs of existing entries that citations were linked
+// to, sorted
+func (l *Library) Linked() []string {
+	seen := make(map[string]bool)
+	var ids []string
+	for _, d := range l.Decisions() {
+		if d.Action == "link" && !seen[d.ID] {
+			seen[d.ID] = true
+			ids = append(ids, d.ID)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Created returns the references added by Resolve, sorted by ID
+func (l *Library) Created() []*Reference {
+	var created []*Reference
+	for _, d := range l.Decisions() {
+		if d.Action == "create" {
+			created = append(created, d.Reference)
+		}
+	}
+	sort.Slice(created, func(i, j int) bool {
+		return created[i].ID < created[j].ID
+	})
+	return created
+}
+
+// WriteNew writes a file for every reference added by Resolve. Existing
+// files are never overwritten. It returns the paths of the files written.
+func (l *Library) WriteNew() ([]string, error) {
+	if l.dir == "" {
+		return nil, nil
+	}
+
+	var written []string
+	for _, ref := range l.Created() {
+		filename := filepath.Join(l.dir, ref.ID+".yaml")
+		if _, err := os.Stat(filename); err == nil {
+			continue
+		}
+		data, err := ref.Marshal()
+		if err != nil {
+			return written, err
+		}
+		if err := os.WriteFile(filename, data, 0644); err != nil {
+			return written, fmt.Errorf("failed to write reference: %w", err)
+		}
+		written = append(written, filename)
+	}
+
+	return written, nil
+}
+
+// normalizeDOI lowercases a DOI and strips resolver prefixes; DOIs are
+// case-insensitive
+func normalizeDOI(doi string) string {
+	doi = strings.ToLower(strings.TrimSpace(doi))
+	for _, prefix := range []string{"https://doi.org/", "http://doi.org/", "https://dx.doi.org/", "http://dx.doi.org/", "doi:"} {
+		doi = strings.TrimPrefix(doi, prefix)
+	}
+	return strings.TrimSpace(doi)
+}
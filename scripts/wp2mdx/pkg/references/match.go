@@ -0,0 +1,122 @@
+package references
+
+import (
+	"strings"
+
+	"github.com/aequinox/gesundes-leben/wp2mdx/pkg/parser"
+)
+
+// DefaultThreshold is the score from which a fuzzy match is taken to be
+// the same work
+const DefaultThreshold = 0.85
+
+// Weights of the fuzzy score; they add up to 1
+const (
+	titleWeight  = 0.7
+	authorWeight = 0.2
+	yearWeight   = 0.1
+)
+
+// Match is the library entry closest to a reference. Method is "doi" or
+// "pmid" for identifier matches, which score 1, and "fuzzy" for matches by
+// title, first author and year.
+type Match struct {
+	Entry  *Reference
+	Method string
+	Score  float64
+}
+
+// match finds the closest entry for a reference; the caller holds the lock
+func (l *Library) match(ref *Reference) Match {
+	if doi := normalizeDOI(ref.DOI); doi != "" {
+		if entry := l.byDOI[doi]; entry != nil {
+			return Match{Entry: entry, Method: "doi", Score: 1}
+		}
+	}
+	if ref.PMID != "" {
+		if entry := l.byPMID[ref.PMID]; entry != nil {
+			return Match{Entry: entry, Method: "pmid", Score: 1}
+		}
+	}
+
+	best := Match{}
+	for _, entry := range l.entries {
+		if conflictingIDs(ref, entry) {
+			continue
+		}
+		if score := similarity(ref, entry); score > best.Score {
+			best = Match{Entry: entry, Method: "fuzzy", Score: score}
+		}
+	}
+	return best
+}
+
+// conflictingIDs reports whether two references carry different DOIs or
+// PMIDs, and so are different works however similar they look
+func conflictingIDs(a, b *Reference) bool {
+	if da, db := normalizeDOI(a.DOI), normalizeDOI(b.DOI); da != "" && db != "" && da != db {
+		return true
+	}
+	return a.PMID != "" && b.PMID != "" && a.PMID != b.PMID
+}
+
+// similarity scores how likely two references are the same work, from the
+// similarity of their titles, first authors and years
+func similarity(a, b *Reference) float64 {
+	year := 0.0
+	switch d := a.Year - b.Year; {
+	case d == 0:
+		year = 1
+	case d == 1 || d == -1:
+		// Online-first and print editions are often a year apart
+		year = 0.5
+	default:
+		return 0
+	}
+
+	title := dice(bigrams(normalizeTitle(a.Title)), bigrams(normalizeTitle(b.Title)))
+
+	author := 0.5
+	sa, sb := parser.GenerateSlug(a.FirstAuthorSurname()), parser.GenerateSlug(b.FirstAuthorSurname())
+	if sa != "" && sb != "" {
+		author = dice(bigrams(sa), bigrams(sb))
+	}
+
+	return titleWeight*title + authorWeight*author + yearWeight*year
+}
+
+// normalizeTitle lowercases a title, folds umlauts and drops punctuation,
+// so that spelling variants of the same title compare equal
+func normalizeTitle(title string) string {
+	return strings.ReplaceAll(parser.GenerateSlug(title), "-", " ")
+}
+
+// bigrams returns the character bigrams of s with their counts
+func bigrams(s string) map[string]int {
+	grams := make(map[string]int)
+	runes := []rune(s)
+	for i := 0; i+1 < len(runes); i++ {
+		grams[string(runes[i:i+2])]++
+	}
+	return grams
+}
+
+// dice returns the Sørensen–Dice coefficient of two bigram multisets
+func dice(a, b map[string]int) float64 {
+	total := 0
+	for _, n := range a {
+		total += n
+	}
+	for _, n := range b {
+		total += n
+	}
+	if total == 0 {
+		return 0
+	}
+
+	shared := 0
+	for gram, n := range a {
+		shared += min(n, b[gram])
+	}
+	return 2 * float64(shared) / float64(total)
+}
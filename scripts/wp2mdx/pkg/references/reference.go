@@ -0,0 +1,150 @@
+// Package references extracts the citations of a post's references section
+// ("Quellen") and maps them to entries of the site's references collection
+// (src/data/references/<id>.yaml).
+package references
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/aequinox/gesundes-leben/wp2mdx/pkg/keywords"
+	"github.com/aequinox/gesundes-leben/wp2mdx/pkg/parser"
+	"gopkg.in/yaml.v3"
+)
+
+// Reference is an entry of the references collection. Fields follow the
+// collection schema in src/content.config.ts.
+type Reference struct {
+	ID        string   `yaml:"-"`
+	Type      string   `yaml:"type"`
+	Title     string   `yaml:"title"`
+	Authors   []string `yaml:"authors"`
+	Year      int      `yaml:"year"`
+	Journal   string   `yaml:"journal,omitempty"`
+	Volume    int      `yaml:"volume,omitempty"`
+	Issue     string   `yaml:"issue,omitempty"`
+	Pages     string   `yaml:"pages,omitempty"`
+	Publisher string   `yaml:"publisher,omitempty"`
+	Location  string   `yaml:"location,omitempty"`
+	Edition   string   `yaml:"edition,omitempty"`
+	ISBN      string   `yaml:"isbn,omitempty"`
+	URL       string   `yaml:"url,omitempty"`
+	DOI       string   `yaml:"doi,omitempty"`
+	PMID      string   `yaml:"pmid,omitempty"`
+	Keywords  []string `yaml:"keywords,omitempty"`
+	Abstract  string   `yaml:"abstract,omitempty"`
+}
+
+// idTitleWords is the number of title words in a generated ID
+const idTitleWords = 4
+
+// englishStopwords are left out of IDs, in addition to German stopwords
+var englishStopwords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true,
+	"by": true, "for": true, "from": true, "in": true, "into": true, "is": true,
+	"of": true, "on": true, "or": true, "the": true, "their": true, "to": true,
+	"via": true, "with": true, "without": true,
+}
+
+// GenerateID builds an ID in the site's naming scheme: year, first
+// author's surname and the first significant title words, e.g.
+// "1955-waldo-ascorbic-acid-leukemic-patients"
+func (r *Reference) GenerateID() string {
+	parts := []string{}
+	if r.Year > 0 {
+		parts = append(parts, strconv.Itoa(r.Year))
+	}
+	if surname := r.FirstAuthorSurname(); surname != "" {
+		parts = append(parts, parser.GenerateSlug(surname))
+	}
+
+	n := 0
+	for _, word := range strings.Fields(strings.ToLower(r.Title)) {
+		if keywords.IsStopword(strings.Trim(word, ".,:;?!()")) {
+			continue
+		}
+		word = parser.GenerateSlug(word)
+		if len(word) < 3 || englishStopwords[word] {
+			continue
+		}
+		parts = append(parts, word)
+		if n++; n == idTitleWords {
+			break
+		}
+	}
+
+	return parser.GenerateSlug(strings.Join(parts, " "))
+}
+
+// FirstAuthorSurname returns the surname of the first author, given as
+// "Surname, I." in the collection
+func (r *Reference) FirstAuthorSurname() string {
+	if len(r.Authors) == 0 {
+		return ""
+	}
+	surname, _, _ := strings.Cut(r.Authors[0], ",")
+	return strings.TrimSpace(surname)
+}
+
+// Marshal renders the reference as YAML in the style of the collection:
+// two-space indentation and double-quoted strings
+func (r *Reference) Marshal() ([]byte, error) {
+	var node yaml.Node
+	if err := node.Encode(r); err != nil {
+		return nil, fmt.Errorf("failed to encode reference: %w", err)
+	}
+	quoteStrings(&node, "")
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&node); err != nil {
+		return nil, fmt.Errorf("failed to encode reference: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, fmt.Errorf("failed to encode reference: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// quoteStrings double-quotes the string values below node, except for the
+// type enum and numeric issues
+func quoteStrings(node *yaml.Node, key string) {
+	switch node.Kind {
+	case yaml.DocumentNode, yaml.SequenceNode:
+		for _, child := range node.Content {
+			quoteStrings(child, key)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			quoteStrings(node.Content[i+1], node.Content[i].Value)
+		}
+	case yaml.ScalarNode:
+		if node.Tag != "!!str" {
+			return
+		}
+		switch {
+		case key == "type":
+		case key == "issue" && isDigits(node.Value):
+			node.Tag = "!!int"
+			node.Style = 0
+		default:
+			node.Style = yaml.DoubleQuotedStyle
+		}
+	}
+}
+
+// isDigits reports whether s is a non-empty string of ASCII digits
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
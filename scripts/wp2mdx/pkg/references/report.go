@@ -0,0 +1,95 @@
+package references
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// reviewScore is the score from which the closest entry of a new
+// reference is shown for review; lower scores are unrelated works
+const reviewScore = 0.6
+
+// WriteReport writes a Markdown merge report of the decisions made so far:
+// which citations link to existing entries and which entries are created.
+// New entries are listed with their closest existing entry, most similar
+// first, so near-duplicates below the threshold can be reviewed.
+func (l *Library) WriteReport(w io.Writer) error {
+	var linked, created []Decision
+	reused := make(map[string][]string)
+	for _, d := range l.Decisions() {
+		switch d.Action {
+		case "link":
+			linked = append(linked, d)
+		case "create":
+			created = append(created, d)
+		case "reuse":
+			if !slices.Contains(reused[d.ID], d.Source) {
+				reused[d.ID] = append(reused[d.ID], d.Source)
+			}
+		}
+	}
+	sort.SliceStable(linked, func(i, j int) bool {
+		return linked[i].Match.Score < linked[j].Match.Score
+	})
+	sort.SliceStable(created, func(i, j int) bool {
+		return created[i].Match.Score > created[j].Match.Score
+	})
+
+	var sb strings.Builder
+	sb.WriteString("# Reference merge report\n\n")
+	fmt.Fprintf(&sb, "Library: %d entries, threshold: %.2f\n\n", l.Len(), l.Threshold())
+	fmt.Fprintf(&sb, "- Link to existing entries: %d\n", len(linked))
+	fmt.Fprintf(&sb, "- Create new entries: %d\n", len(created))
+
+	sb.WriteString("\n## Link to existing entries\n\n")
+	if len(linked) == 0 {
+		sb.WriteString("None.\n")
+	} else {
+		sb.WriteString("| Score | Method | Entry | Citation | Source |\n")
+		sb.WriteString("| --- | --- | --- | --- | --- |\n")
+		for _, d := range linked {
+			fmt.Fprintf(&sb, "| %.2f | %s | %s | %s | %s |\n",
+				d.Match.Score, d.Match.Method, cell(d.ID), cell(citation(d.Reference)), cell(d.Source))
+		}
+	}
+
+	sb.WriteString("\n## Create new entries\n\n")
+	if len(created) == 0 {
+		sb.WriteString("None.\n")
+	} else {
+		sb.WriteString("| Entry | Citation | Closest existing | Score | Source |\n")
+		sb.WriteString("| --- | --- | --- | --- | --- |\n")
+		for _, d := range created {
+			closest, score := "-", "-"
+			if d.Match.Entry != nil && d.Match.Score >= reviewScore {
+				closest, score = d.Match.Entry.ID, fmt.Sprintf("%.2f", d.Match.Score)
+			}
+			sources := []string{d.Source}
+			for _, source := range reused[d.ID] {
+				if source != d.Source {
+					sources = append(sources, source)
+				}
+			}
+			fmt.Fprintf(&sb, "| %s | %s | %s | %s | %s |\n",
+				cell(d.ID), cell(citation(d.Reference)), cell(closest), score, cell(strings.Join(sources, "; ")))
+		}
+	}
+
+	if _, err := io.WriteString(w, sb.String()); err != nil {
+		return fmt.Errorf("failed to write reference report: %w", err)
+	}
+	return nil
+}
+
+// citation renders a reference briefly, e.g. "Ghosh (2023) Microplastics as…"
+func citation(ref *Reference) string {
+	return fmt.Sprintf("%s (%d) %s", ref.FirstAuthorSurname(), ref.Year, truncate(ref.Title, 60))
+}
+
+// cell escapes a value for a Markdown table cell
+func cell(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
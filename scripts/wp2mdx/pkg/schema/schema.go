@@ -0,0 +1,138 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/aequinox/gesundes-leben/wp2mdx/pkg/models"
+)
+
+// Schema describes the constraints of the Astro blog collection
+// (src/content.config.ts) that generated frontmatter must satisfy
+type Schema struct {
+	Categories           []string `json:"categories"`
+	Groups               []string `json:"groups"`
+	DescriptionMinLength int      `json:"descriptionMinLength"`
+	RequireHeroImage     bool     `json:"requireHeroImage"`
+}
+
+// Violation is a single schema constraint a post fails
+type Violation struct {
+	Field   string
+	Message string
+}
+
+// String formats the violation for reports
+func (v Violation) String() string {
+	return fmt.Sprintf("%s: %s", v.Field, v.Message)
+}
+
+// ValidationError is returned for a post whose frontmatter fails the schema
+type ValidationError struct {
+	PostID     string
+	Title      string
+	Violations []Violation
+}
+
+// Error implements the error interface
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		messages[i] = v.String()
+	}
+	return fmt.Sprintf("frontmatter of post %s (%s) violates schema: %s",
+		e.PostID, e.Title, strings.Join(messages, "; "))
+}
+
+// Default returns the constraints of the site's blog collection, mirroring
+// CATEGORIES and GROUPS in src/utils/types.ts
+func Default() *Schema {
+	return &Schema{
+		Categories: []string{
+			"Ernährung",
+			"Immunsystem",
+			"Lesenswertes",
+			"Lifestyle & Psyche",
+			"Mikronährstoffe",
+			"Organsysteme",
+			"Wissenschaftliches",
+			"Wissenswertes",
+		},
+		Groups:               []string{"pro", "kontra", "fragezeiten"},
+		DescriptionMinLength: 10,
+		RequireHeroImage:     true,
+	}
+}
+
+// Load reads a schema from a JSON file. An empty filename returns the
+// default schema; fields missing from the file keep their defaults.
+func Load(filename string) (*Schema, error) {
+	s := Default()
+	if filename == "" {
+		return s, nil
+	}
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema: %w", err)
+	}
+
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("failed to parse schema: %w", err)
+	}
+
+	if len(s.Categories) == 0 || len(s.Groups) == 0 {
+		return nil, fmt.Errorf("schema %s must list categories and groups", filename)
+	}
+
+	return s, nil
+}
+
+// Validate checks frontmatter against the schema and returns all violations
+func (s *Schema) Validate(fm *models.Frontmatter) []Violation {
+	var violations []Violation
+
+	if strings.TrimSpace(fm.Title) == "" {
+		violations = append(violations, Violation{"title", "must not be empty"})
+	}
+
+	if n := utf8.RuneCountInString(strings.TrimSpace(fm.Description)); n < s.DescriptionMinLength {
+		violations = append(violations, Violation{"description",
+			fmt.Sprintf("must be at least %d characters (got %d)", s.DescriptionMinLength, n)})
+	}
+
+	for _, category := range fm.Categories {
+		if !contains(s.Categories, category) {
+			violations = append(violations, Violation{"categories",
+				fmt.Sprintf("%q is not one of %s", category, strings.Join(s.Categories, ", "))})
+		}
+	}
+
+	if !contains(s.Groups, fm.Group) {
+		violations = append(violations, Violation{"group",
+			fmt.Sprintf("%q is not one of %s", fm.Group, strings.Join(s.Groups, "|"))})
+	}
+
+	if s.RequireHeroImage {
+		if fm.HeroImage == nil {
+			violations = append(violations, Violation{"heroImage", "is required"})
+		} else if strings.TrimSpace(fm.HeroImage.Src) == "" {
+			violations = append(violations, Violation{"heroImage.src", "must not be empty"})
+		}
+	}
+
+	return violations
+}
+
+// contains reports whether values includes value
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
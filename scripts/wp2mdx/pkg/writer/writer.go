@@ -4,31 +4,58 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
 
 	"github.com/aequinox/gesundes-leben/wp2mdx/pkg/config"
 	"github.com/aequinox/gesundes-leben/wp2mdx/pkg/converter"
 	"github.com/aequinox/gesundes-leben/wp2mdx/pkg/frontmatter"
+	"github.com/aequinox/gesundes-leben/wp2mdx/pkg/glossary"
 	"github.com/aequinox/gesundes-leben/wp2mdx/pkg/images"
+	"github.com/aequinox/gesundes-leben/wp2mdx/pkg/links"
 	"github.com/aequinox/gesundes-leben/wp2mdx/pkg/models"
+	"github.com/aequinox/gesundes-leben/wp2mdx/pkg/parser"
+	"github.com/aequinox/gesundes-leben/wp2mdx/pkg/schema"
 )
 
 // Writer handles writing MDX files
 type Writer struct {
-	config    *config.Config
-	generator *frontmatter.Generator
-	converter *converter.Converter
+	config      *config.Config
+	attachments *parser.AttachmentIndex
+	schema      *schema.Schema
+	generator   *frontmatter.Generator
+	converter   *converter.Converter
+	links       *links.Index
+	glossary    *glossary.Glossary
 }
 
-// New creates a new MDX writer
-func New(cfg *config.Config) *Writer {
+// New creates a new MDX writer. The attachment index resolves resized image
+// variants and gallery IDs in the content to their downloaded originals and
+// may be nil.
+// Generated frontmatter is checked against s; a nil schema skips validation.
+func New(cfg *config.Config, attachments *parser.AttachmentIndex, s *schema.Schema) *Writer {
 	return &Writer{
-		config:    cfg,
-		generator: frontmatter.New(cfg),
-		converter: converter.New(),
+		config:      cfg,
+		attachments: attachments,
+		schema:      s,
+		generator:   frontmatter.New(cfg),
+		converter:   converter.New(attachments),
 	}
 }
 
+// SetLinkIndex sets the permalinks of the export that links in the content
+// are resolved against. Without one, links are left unchanged.
+func (w *Writer) SetLinkIndex(index *links.Index) {
+	w.links = index
+}
+
+// SetGlossary sets the glossary whose terms are linked on their first
+// mention in each post. Without one, no terms are linked.
+func (w *Writer) SetGlossary(g *glossary.Glossary) {
+	w.glossary = g
+}
+
 // WritePost writes a single post to an MDX file
 func (w *Writer) WritePost(post *models.Post) error {
 	// Determine output directory for this post
@@ -37,6 +64,17 @@ func (w *Writer) WritePost(post *models.Post) error {
 		return fmt.Errorf("failed to determine output directory: %w", err)
 	}
 
+	// Generate frontmatter
+	fm, err := w.generator.Generate(post)
+	if err != nil {
+		return fmt.Errorf("failed to generate frontmatter: %w", err)
+	}
+
+	// Validate against the blog collection schema before writing anything
+	if err := w.validate(post, fm); err != nil {
+		return err
+	}
+
 	// Create output directory
 	if !w.config.DryRun {
 		if err := os.MkdirAll(outputDir, 0755); err != nil {
@@ -44,29 +82,32 @@ func (w *Writer) WritePost(post *models.Post) error {
 		}
 	}
 
-	// Generate frontmatter
-	fm, err := w.generator.Generate(post)
-	if err != nil {
-		return fmt.Errorf("failed to generate frontmatter: %w", err)
-	}
-
 	// Convert content to Markdown
-	markdown, err := w.converter.Convert(post.Content)
+	result, err := w.converter.ConvertContent(post.Content)
 	if err != nil {
 		return fmt.Errorf("failed to convert content: %w", err)
 	}
+	post.Warnings = append(post.Warnings, result.Warnings...)
 
-	// Build image variable map
-	imageVars := make(map[string]string)
-	if post.HeroImage != nil {
-		imageVars[post.HeroImage.URL] = post.HeroImage.Variable
+	// Replace markdown images with Astro Image components
+	markdown := converter.ConvertToImageComponentFunc(result.Markdown, w.imageResolver(post))
+
+	// Point links between posts to their new routes
+	if w.links != nil && w.config.RewriteLinks {
+		markdown = converter.RewriteLinksFunc(markdown, w.linkResolver(post), w.config.LinkComponent)
 	}
-	for _, img := range post.Images {
-		imageVars[img.URL] = img.Variable
+
+	// Link the first mention of each glossary term
+	if w.glossary != nil {
+		linker := w.glossary.NewLinker()
+		markdown = converter.LinkTermsFunc(markdown, linker.Link)
+		for _, term := range linker.Linked() {
+			post.GlossaryTerms = append(post.GlossaryTerms, term.Title)
+		}
 	}
 
-	// Replace markdown images with Astro Image components
-	markdown = converter.ConvertToImageComponent(markdown, imageVars)
+	// Estimate the reading time on the final Markdown
+	fm.ReadingTime = converter.EstimateReadingTime(markdown, w.config.ReadingWPM, w.config.ReadingImageSeconds).Minutes
 
 	// Generate the complete MDX file
 	mdxContent := w.buildMDX(fm, post, markdown)
@@ -93,6 +134,110 @@ func (w *Writer) WritePost(post *models.Post) error {
 	return nil
 }
 
+// Validate checks the frontmatter of a post against the schema before its
+// images are downloaded, so in strict mode a failing post leaves no files.
+// The hero image counts as present if it has a URL to download.
+func (w *Writer) Validate(post *models.Post) error {
+	fm, err := w.generator.Generate(post)
+	if err != nil {
+		return fmt.Errorf("failed to generate frontmatter: %w", err)
+	}
+	if fm.HeroImage != nil && fm.HeroImage.Src == "" {
+		fm.HeroImage.Src = post.HeroImage.URL
+	}
+	return w.validate(post, fm)
+}
+
+// validate checks the frontmatter against the schema. Violations are
+// recorded as post warnings; in strict mode they also fail the post.
+func (w *Writer) validate(post *models.Post, fm *models.Frontmatter) error {
+	if w.schema == nil {
+		return nil
+	}
+
+	violations := w.schema.Validate(fm)
+	if len(violations) == 0 {
+		return nil
+	}
+
+	// Posts checked by Validate are checked again when written
+	for _, v := range violations {
+		if warning := "schema: " + v.String(); !slices.Contains(post.Warnings, warning) {
+			post.Warnings = append(post.Warnings, warning)
+		}
+	}
+
+	if w.config.StrictSchema {
+		return &schema.ValidationError{
+			PostID:     fm.ID,
+			Title:      fm.Title,
+			Violations: violations,
+		}
+	}
+
+	return nil
+}
+
+// imageResolver returns a lookup from image URLs in the content to the
+// downloaded images. URLs without a direct match are resolved through the
+// attachment index, so any size variant of a downloaded attachment maps to
+// its image.
+func (w *Writer) imageResolver(post *models.Post) func(src string) (*models.ImageRef, bool) {
+	byURL := make(map[string]*models.ImageRef)
+	byAttachment := make(map[string]*models.ImageRef)
+
+	add := func(img *models.ImageRef) {
+		if img.Variable == "" {
+			return
+		}
+		byURL[img.URL] = img
+		if img.ID != "" {
+			byAttachment[img.ID] = img
+		}
+	}
+
+	if post.HeroImage != nil {
+		add(post.HeroImage)
+	}
+	for i := range post.Images {
+		add(&post.Images[i])
+	}
+
+	return func(src string) (*models.ImageRef, bool) {
+		if img, ok := byURL[src]; ok {
+			return img, true
+		}
+		if attachment := w.attachments.ByURL(src); attachment != nil {
+			img, ok := byAttachment[strconv.Itoa(attachment.ID)]
+			return img, ok
+		}
+		return nil, false
+	}
+}
+
+// linkResolver returns a lookup from hrefs in the content to the routes of
+// converted posts. Internal links to content that is missing, unpublished
+// or not converted are kept and reported as warnings.
+func (w *Writer) linkResolver(post *models.Post) func(href string) (string, bool) {
+	return func(href string) (string, bool) {
+		target, fragment, internal := w.links.Resolve(href)
+		switch {
+		case !internal:
+			return "", false
+		case target == nil:
+			post.Warnings = append(post.Warnings, fmt.Sprintf("link to missing content: %s", href))
+			return "", false
+		case !target.Published():
+			post.Warnings = append(post.Warnings, fmt.Sprintf("link to unpublished %s %q (%s): %s", target.Type, target.Title, target.Status, href))
+			return "", false
+		case !target.Converted:
+			post.Warnings = append(post.Warnings, fmt.Sprintf("link to %s %q, which is not converted: %s", target.Type, target.Title, href))
+			return "", false
+		}
+		return target.Route() + fragment, true
+	}
+}
+
 // GetOutputDirectory determines the output directory for a post
 func (w *Writer) GetOutputDirectory(post *models.Post) (string, error) {
 	base := w.config.OutputDir
@@ -154,10 +299,13 @@ func (w *Writer) buildMDX(fm *models.Frontmatter, post *models.Post, markdown st
 	sb.WriteString(yamlStr)
 	sb.WriteString("---\n\n")
 
-	// Write imports
-	importsStr := images.GenerateImports(post)
-	if importsStr != "" {
-		sb.WriteString(importsStr)
+	// Write component imports followed by image imports
+	imports := converter.ComponentImports(markdown)
+	if importsStr := images.GenerateImports(post); importsStr != "" {
+		imports = append(imports, importsStr)
+	}
+	if len(imports) > 0 {
+		sb.WriteString(strings.Join(imports, "\n"))
 		sb.WriteString("\n\n")
 	}
 